@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// sparkBars are the characters of the sparklines, from no commits to the most
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// printRepositories prints a table of the repositories, most commits
+// first, with the commits and the active days in the time range and the
+// dates of the first and the last commit. With `sparkline` there's also
+// a sparkline of the weekly commits of each repository.
+func printRepositories(rep *report, sparkline bool) {
+	rows, _ := repositoryRows(rep)
+	// the rows are sorted, so the sparklines are looked up by path
+	sparklines := make(map[string]string)
+	if sparkline {
+		for _, repo := range rep.repos {
+			sparklines[repo.path] = weeklySparkline(repo.commits, rep.r)
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := "#\tCOMMITS\tACTIVE DAYS\tFIRST\tLAST\t"
+	if sparkline {
+		header += "ACTIVITY\t"
+	}
+	fmt.Fprintln(tw, header+"  REPOSITORY")
+	for i, row := range rows {
+		first, last := "-", "-"
+		if row.Commits > 0 {
+			first = rep.r.inZone(row.First).Format(dateFormat)
+			last = rep.r.inZone(row.Last).Format(dateFormat)
+		}
+		line := fmt.Sprintf("%d\t%d\t%d\t%s\t%s\t", i+1, row.Commits, row.ActiveDays, first, last)
+		if sparkline {
+			line += sparklines[row.Path] + "\t"
+		}
+		// the paths are left aligned, after the last tab
+		fmt.Fprintln(tw, line+"  "+row.Path)
+	}
+	tw.Flush()
+}
+
+// weeklySparkline returns a character for each week of the time range,
+// oldest first, with the height relative to the week with the most commits
+func weeklySparkline(records []commitRecord, r timeRange) string {
+	weeks := make([]int, (r.days()+6)/7)
+	max := 0
+	for _, record := range records {
+		daysAgo := countDaysSinceDate(record.When, r)
+		if daysAgo == outOfRange {
+			continue
+		}
+		week := len(weeks) - 1 - daysAgo/7
+		weeks[week]++
+		if weeks[week] > max {
+			max = weeks[week]
+		}
+	}
+
+	var b strings.Builder
+	for _, commits := range weeks {
+		level := 0
+		if commits > 0 {
+			level = commits * (len(sparkBars) - 1) / max
+			if level == 0 {
+				level = 1
+			}
+		}
+		b.WriteRune(sparkBars[level])
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheEntry is the result of the history walk of a repository, stored
+// on disk so that the next runs only walk the commits added since
+type cacheEntry struct {
+	Path string `json:"path"`
+	// Tips are the hashes of the commits the walk started from
+	Tips []string `json:"tips"`
+	// Since is the time the walk stopped at
+	Since   time.Time      `json:"since"`
+	Commits []commitRecord `json:"commits"`
+}
+
+// getCacheDir returns the folder holding the cache files,
+// $XDG_CACHE_HOME/gogitlocalstats or ~/.cache/gogitlocalstats
+func getCacheDir() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		cacheHome = getHomeDir() + "/.cache"
+	}
+	return cacheHome + "/gogitlocalstats"
+}
+
+// cacheVersion is bumped when the cached data changes,
+// so that the old cache files are ignored
+const cacheVersion = 6
+
+// getCacheFilePath returns the cache file of the repository found in
+// `path`, for the walk described by `key`
+func getCacheFilePath(path string, key string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d\n%s\n%s", cacheVersion, path, key)))
+	return getCacheDir() + "/" + hex.EncodeToString(sum[:]) + ".json"
+}
+
+// loadCache returns the entry stored in `filePath`, or nil
+// if there's none or it can't be read
+func loadCache(filePath string) *cacheEntry {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(content, entry); err != nil {
+		return nil
+	}
+	return entry
+}
+
+// saveCache stores `entry` in `filePath`, replacing it atomically
+// as other processes may be reading it
+func saveCache(filePath string, entry *cacheEntry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filePath), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var reposCommands = []*command{
+	{"repos list", "", "list the tracked repositories", runReposList},
+	{"repos remove", "<path>...", "stop tracking the repositories in <path>", runReposRemove},
+}
+
+// runScan scans the folders passed as arguments and adds the repositories
+// found to the dot file
+func runScan(cmd *command, args []string) int {
+	fs := cmd.flagSet()
+	dryRun := fs.Bool("dry-run", false, "print the repositories found without adding them")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		return usageError(fs, "missing folder to scan")
+	}
+
+	startingTime := time.Now().UTC()
+	for _, folder := range fs.Args() {
+		if *dryRun {
+			recursiveScanFolder(folder)
+			continue
+		}
+		if err := scan(folder); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+	endingTime := time.Now().UTC()
+	fmt.Println(endingTime.Sub(startingTime))
+
+	return exitOK
+}
+
+// runStats prints the contributions graph
+func runStats(cmd *command, args []string) int {
+	fs := cmd.flagSet()
+	sf := addStatsFlags(fs)
+	format := fs.String("format", "graph", "the output `format`: graph, "+exportFormats())
+	byRepo := fs.Bool("by-repo", false, "print a table of the repositories, most commits first, instead of the graph")
+	sparkline := fs.Bool("sparkline", false, "with -by-repo, add the weekly commits of each repository")
+	byLanguage := fs.Bool("by-language", false, "print a table of the languages, most lines changed first, instead of the graph (implies -lines)")
+	eo := addExportFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected arguments: "+strings.Join(fs.Args(), " "))
+	}
+	opts, err := sf.options(time.Now())
+	if err != nil {
+		return usageError(fs, err.Error())
+	}
+	if (*byRepo || *byLanguage) && *format != "graph" {
+		return usageError(fs, "-by-repo and -by-language can't be used with -format")
+	}
+	if *byRepo && *byLanguage {
+		return usageError(fs, "-by-repo and -by-language can't be used together")
+	}
+	if *sparkline && !*byRepo {
+		return usageError(fs, "-sparkline needs -by-repo")
+	}
+
+	if *format != "graph" {
+		return export(os.Stdout, opts, *format, eo, fs)
+	}
+
+	print := printGraph
+	switch {
+	case *byRepo:
+		print = func(rep *report) {
+			printRepositories(rep, *sparkline)
+		}
+	case *byLanguage:
+		// the languages come from the diffs
+		opts.lines = true
+		print = printLanguages
+	}
+
+	startingTime := time.Now().UTC()
+	failures, err := stats(opts, print)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	endingTime := time.Now().UTC()
+	fmt.Println(endingTime.Sub(startingTime))
+
+	if opts.strict && len(failures) > 0 {
+		return exitError
+	}
+
+	return exitOK
+}
+
+// runRepos dispatches to the `repos` subcommands
+func runRepos(cmd *command, args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr, "gogitlocalstats repos", reposCommands)
+		return exitUsage
+	}
+	if isHelp(args[0]) {
+		usage(os.Stdout, "gogitlocalstats repos", reposCommands)
+		return exitOK
+	}
+
+	sub := findCommand(reposCommands, "repos "+args[0])
+	if sub == nil {
+		fmt.Fprintf(os.Stderr, "unknown repos command %q\n\n", args[0])
+		usage(os.Stderr, "gogitlocalstats repos", reposCommands)
+		return exitUsage
+	}
+
+	return sub.run(sub, args[1:])
+}
+
+// runReposList prints the repositories stored in the dot file
+func runReposList(cmd *command, args []string) int {
+	fs := cmd.flagSet()
+	missing := fs.Bool("missing", false, "only list the repositories whose folder no longer exists")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected arguments: "+strings.Join(fs.Args(), " "))
+	}
+
+	repos, err := parseFileLinesToSlice(getDotFilePath())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	for _, repo := range repos {
+		if *missing && folderExists(parseRepoLine(repo).path) {
+			continue
+		}
+		fmt.Println(repo)
+	}
+
+	return exitOK
+}
+
+// runReposRemove removes the repositories passed as arguments from the dot file
+func runReposRemove(cmd *command, args []string) int {
+	fs := cmd.flagSet()
+	missing := fs.Bool("missing", false, "remove all the repositories whose folder no longer exists")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 && !*missing {
+		return usageError(fs, "missing repository to remove")
+	}
+
+	filePath := getDotFilePath()
+	repos, err := parseFileLinesToSlice(filePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	var toRemove []string
+	for _, path := range fs.Args() {
+		toRemove = append(toRemove, strings.TrimSuffix(path, "/"))
+		if abs, err := filepath.Abs(path); err == nil {
+			toRemove = append(toRemove, abs)
+		}
+	}
+	if *missing {
+		for _, repo := range repos {
+			if path := parseRepoLine(repo).path; !folderExists(path) {
+				toRemove = append(toRemove, path)
+			}
+		}
+	}
+
+	var kept []string
+	removed := 0
+	for _, repo := range repos {
+		if sliceContains(toRemove, parseRepoLine(repo).path) {
+			fmt.Printf("removed %s\n", repo)
+			removed++
+			continue
+		}
+		kept = append(kept, repo)
+	}
+	if err := dumpStringsSliceToFile(kept, filePath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if removed == 0 && fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "no matching repository found")
+		return exitError
+	}
+
+	return exitOK
+}
+
+// runConfig prints or sets the configuration values
+func runConfig(cmd *command, args []string) int {
+	fs := cmd.flagSet()
+	unset := fs.Bool("unset", false, "remove the value of `key`")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 2 && !listKeys[fs.Arg(0)] || *unset && fs.NArg() != 1 {
+		return usageError(fs, "wrong number of arguments")
+	}
+
+	config, err := readConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if fs.NArg() == 0 {
+		keys := make([]string, 0, len(configKeys))
+		for key := range configKeys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, value := range strings.Split(config[key], "\n") {
+				fmt.Printf("%s = %s\t# %s\n", key, value, configKeys[key])
+			}
+		}
+		return exitOK
+	}
+
+	key := fs.Arg(0)
+	if _, ok := configKeys[key]; !ok {
+		return usageError(fs, fmt.Sprintf("unknown configuration key %q", key))
+	}
+
+	switch {
+	case *unset:
+		delete(config, key)
+	case fs.NArg() >= 2:
+		// the lists take a value per argument
+		config[key] = strings.Join(fs.Args()[1:], "\n")
+	default:
+		if value, ok := config[key]; ok {
+			fmt.Println(value)
+		}
+		return exitOK
+	}
+
+	if err := writeConfig(config); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	return exitOK
+}
+
+// runPunchcard prints the punch card of the commits
+func runPunchcard(cmd *command, args []string) int {
+	fs := cmd.flagSet()
+	sf := addStatsFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected arguments: "+strings.Join(fs.Args(), " "))
+	}
+	opts, err := sf.options(time.Now())
+	if err != nil {
+		return usageError(fs, err.Error())
+	}
+
+	failures, err := stats(opts, printPunchcard)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if opts.strict && len(failures) > 0 {
+		return exitError
+	}
+
+	return exitOK
+}
+
+// runExport writes the daily commit counts to the standard output,
+// or to the file passed with -o
+func runExport(cmd *command, args []string) int {
+	fs := cmd.flagSet()
+	sf := addStatsFlags(fs)
+	format := fs.String("format", "text", "the output `format`: "+exportFormats())
+	eo := addExportFlags(fs)
+	output := fs.String("o", "", "write to `file` instead of the standard output")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected arguments: "+strings.Join(fs.Args(), " "))
+	}
+	opts, err := sf.options(time.Now())
+	if err != nil {
+		return usageError(fs, err.Error())
+	}
+	if _, ok := exporters[*format]; !ok {
+		return usageError(fs, fmt.Sprintf("unknown format %q", *format))
+	}
+	if err := eo.validate(); err != nil {
+		return usageError(fs, err.Error())
+	}
+
+	w := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return export(w, opts, *format, eo, fs)
+}
+
+// export writes the stats to `w` in the export `format`, returning the
+// exit code of the command whose flags are `fs`
+func export(w io.Writer, opts *statsOptions, format string, eo *exportOptions, fs *flag.FlagSet) int {
+	exporter, ok := exporters[format]
+	if !ok {
+		return usageError(fs, fmt.Sprintf("unknown format %q", format))
+	}
+	if err := eo.validate(); err != nil {
+		return usageError(fs, err.Error())
+	}
+
+	rep, err := processRepositories(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if err := exporter(w, rep, eo); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	printFailures(rep.failures)
+
+	if opts.strict && len(rep.failures) > 0 {
+		return exitError
+	}
+
+	return exitOK
+}
+
+// runServe serves the dashboard, the JSON API and the metrics of the stats
+func runServe(cmd *command, args []string) int {
+	fs := cmd.flagSet()
+	addr := fs.String("addr", ":8080", "the `address` to listen on")
+	refresh := fs.Duration("metrics-refresh", time.Minute, "calculate the metrics again after `duration`, serving the last ones until then")
+	sf := addStatsFlags(fs)
+	eo := addExportFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected arguments: "+strings.Join(fs.Args(), " "))
+	}
+	if _, err := sf.options(time.Now()); err != nil {
+		return usageError(fs, err.Error())
+	}
+	if err := eo.validate(); err != nil {
+		return usageError(fs, err.Error())
+	}
+
+	fmt.Printf("Listening on %s\n", *addr)
+	if err := http.ListenAndServe(*addr, newServer(sf, eo, *refresh)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	return exitOK
+}
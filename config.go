@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// configKeys lists the keys accepted in the configuration file,
+// with their description
+var configKeys = map[string]string{
+	"email":  "the default emails to scan, comma separated",
+	"author": "the default author patterns to scan, one per line",
+	// the commit filters
+	"exclude-message": "the message regexps of the commits to skip, one per line",
+	"generated":       "the globs of the generated paths, the commits touching only these are skipped",
+}
+
+// listKeys are the keys holding a list of patterns, repeated on a line
+// each, as the patterns may contain commas. The values are stored in the
+// configuration map joined by newlines.
+var listKeys = map[string]bool{
+	"author":          true,
+	"exclude-message": true,
+}
+
+// getConfigFilePath returns the path of the configuration file
+func getConfigFilePath() string {
+	return getHomeDir() + "/.gogitlocalstatsrc"
+}
+
+// readConfig parses the `key = value` lines of the configuration file.
+// Empty lines and lines starting with `#` are skipped.
+// A missing configuration file is an empty configuration.
+func readConfig() (map[string]string, error) {
+	config := make(map[string]string)
+	if _, err := os.Stat(getConfigFilePath()); os.IsNotExist(err) {
+		return config, nil
+	}
+
+	lines, err := parseFileLinesToSlice(getConfigFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected `key = value`", getConfigFilePath(), i+1)
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if prev, ok := config[key]; ok && listKeys[key] {
+			value = prev + "\n" + value
+		}
+		config[key] = value
+	}
+
+	return config, nil
+}
+
+// writeConfig stores `config` to the configuration file, sorted by key
+func writeConfig(config map[string]string) error {
+	lines := make([]string, 0, len(config))
+	for key, value := range config {
+		for _, v := range strings.Split(value, "\n") {
+			lines = append(lines, key+" = "+v)
+		}
+	}
+	sort.Strings(lines)
+	return dumpStringsSliceToFile(lines, getConfigFilePath())
+}
+
+// configValue returns the configured value for `key`, or `fallback`
+// if it's not set or the configuration can't be read
+func configValue(key string, fallback string) string {
+	config, err := readConfig()
+	if err != nil {
+		return fallback
+	}
+	if value, ok := config[key]; ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// configValues returns the configured values of the list `key`
+func configValues(key string) []string {
+	var values []string
+	for _, v := range strings.Split(configValue(key, ""), "\n") {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
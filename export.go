@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dateFormat is the format of the dates in the exported data
+const dateFormat = "2006-01-02"
+
+// defaultPalette are the colors of the graph images, from no commits
+// to the most commits, or lines changed, as levelled by cellLevel
+const defaultPalette = "#ebedf0,#9be9a8,#40c463,#216e39"
+
+// exportOptions holds the options of the export formats
+type exportOptions struct {
+	// columns adds a column per "repo" or per "identity" to the CSV and TSV exports
+	columns string
+	// the geometry and colors of the SVG and PNG images
+	cellSize int
+	cellGap  int
+	palette  stringList
+	legend   bool
+}
+
+// addExportFlags defines the export flags in `fs`
+func addExportFlags(fs *flag.FlagSet) *exportOptions {
+	eo := &exportOptions{}
+	fs.StringVar(&eo.columns, "columns", "", "with csv and tsv, add a column per `repo` or per identity")
+	fs.IntVar(&eo.cellSize, "cell-size", 11, "with svg and png, the size of the cells in `pixels`")
+	fs.IntVar(&eo.cellGap, "cell-gap", 3, "with svg and png, the gap between the cells in `pixels`")
+	fs.Var(&eo.palette, "palette", "with svg and png, the 4 `colors` of the cells, from no commits to the most (default "+defaultPalette+")")
+	fs.BoolVar(&eo.legend, "legend", true, "with png, draw the legend of the colors")
+	return eo
+}
+
+// validate returns an error if the export options are not valid,
+// setting the default palette when none is passed
+func (eo *exportOptions) validate() error {
+	if eo.columns != "" && eo.columns != "repo" && eo.columns != "identity" {
+		return fmt.Errorf("-columns must be repo or identity")
+	}
+	if eo.cellSize < 1 || eo.cellGap < 0 {
+		return fmt.Errorf("-cell-size must be positive and -cell-gap not negative")
+	}
+	if len(eo.palette) == 0 {
+		eo.palette.Set(defaultPalette)
+	}
+	if len(eo.palette) != 4 {
+		return fmt.Errorf("-palette needs 4 colors")
+	}
+	for _, c := range eo.palette {
+		if _, err := parseHexColor(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exporters maps the export formats to the functions writing them
+var exporters = map[string]func(w io.Writer, rep *report, eo *exportOptions) error{
+	"text": writeDailyCounts,
+	"json": writeJSON,
+	"csv":  writeCSV(','),
+	"tsv":  writeCSV('\t'),
+	"svg":  writeSVG,
+	"png":  writePNG,
+	"html": writeHTML,
+}
+
+// exportFormats returns the names of the export formats, sorted
+func exportFormats() string {
+	var names []string
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// writeDailyCounts writes to `w` a line for each day, oldest first,
+// with the date and the amount of commits
+func writeDailyCounts(w io.Writer, rep *report, eo *exportOptions) error {
+	commits := rep.dailyCommits()
+	keys := sortMapIntoSlice(commits)
+	for i := len(*keys) - 1; i >= 0; i-- {
+		k := (*keys)[i]
+		date := rep.r.until.AddDate(0, 0, -k)
+		if _, err := fmt.Fprintf(w, "%s\t%d\n", date.Format(dateFormat), (*commits)[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonDay is the amount of commits made in a day
+type jsonDay struct {
+	Date    string `json:"date"`
+	Commits int    `json:"commits"`
+}
+
+// jsonRepository is the breakdown of the commits of a repository
+type jsonRepository struct {
+	Path    string `json:"path"`
+	Commits int    `json:"commits"`
+	// the lines added and removed, only counted with the lines option
+	Additions int       `json:"additions,omitempty"`
+	Deletions int       `json:"deletions,omitempty"`
+	Days      []jsonDay `json:"days"`
+}
+
+// jsonLanguage is the breakdown of the commits of a language
+type jsonLanguage struct {
+	Language string `json:"language"`
+	Commits  int    `json:"commits"`
+	Lines    int    `json:"lines"`
+}
+
+// jsonFailure is a repository that couldn't be processed
+type jsonFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// jsonReport is the JSON export of a report
+type jsonReport struct {
+	Since        string           `json:"since"`
+	Until        string           `json:"until"`
+	Identities   []string         `json:"identities"`
+	Commits      int              `json:"commits"`
+	Additions    int              `json:"additions,omitempty"`
+	Deletions    int              `json:"deletions,omitempty"`
+	Days         []jsonDay        `json:"days"`
+	Repositories []jsonRepository `json:"repositories"`
+	Languages    []jsonLanguage   `json:"languages,omitempty"`
+	Failures     []jsonFailure    `json:"failures"`
+}
+
+// jsonDays converts the `commits` map, keyed by days ago, to a list of
+// days ordered by date. With `skipEmpty` the days without commits are left out.
+func jsonDays(commits *map[int]int, r timeRange, skipEmpty bool) ([]jsonDay, int) {
+	days := []jsonDay{}
+	total := 0
+	keys := sortMapIntoSlice(commits)
+	for i := len(*keys) - 1; i >= 0; i-- {
+		k := (*keys)[i]
+		total += (*commits)[k]
+		if skipEmpty && (*commits)[k] == 0 {
+			continue
+		}
+		date := r.until.AddDate(0, 0, -k)
+		days = append(days, jsonDay{Date: date.Format(dateFormat), Commits: (*commits)[k]})
+	}
+	return days, total
+}
+
+// writeJSON writes the report to `w` as JSON: the time range, the identities,
+// the commits of each day, and for each repository the days with commits
+func writeJSON(w io.Writer, rep *report, eo *exportOptions) error {
+	out := jsonReport{
+		Since:        rep.r.since.Format(dateFormat),
+		Until:        rep.r.until.Format(dateFormat),
+		Identities:   rep.identities(),
+		Repositories: []jsonRepository{},
+		Failures:     []jsonFailure{},
+	}
+	if out.Identities == nil {
+		out.Identities = []string{}
+	}
+	out.Days, out.Commits = jsonDays(rep.dailyCommits(), rep.r, false)
+	_, out.Additions, out.Deletions = rep.totals()
+
+	for _, repo := range rep.repos {
+		commits := make(map[int]int)
+		countDailyCommits(repo.commits, rep.r, &commits)
+		days, total := jsonDays(&commits, rep.r, true)
+		row := jsonRepository{Path: repo.path, Commits: total, Days: days}
+		for _, record := range repo.commits {
+			if countDaysSinceDate(record.When, rep.r) != outOfRange {
+				row.Additions += record.Additions
+				row.Deletions += record.Deletions
+			}
+		}
+		out.Repositories = append(out.Repositories, row)
+	}
+	for _, stats := range rep.languages() {
+		out.Languages = append(out.Languages, jsonLanguage{Language: stats.language, Commits: stats.commits, Lines: stats.lines})
+	}
+	for _, f := range rep.failures {
+		out.Failures = append(out.Failures, jsonFailure{Path: f.path, Error: f.err.Error()})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// writeCSV returns an exporter writing a row for each day, oldest first,
+// with the date and the amount of commits, separating the fields with
+// `comma`. With the `columns` option there's also a column for each
+// repository or for each identity.
+func writeCSV(comma rune) func(w io.Writer, rep *report, eo *exportOptions) error {
+	return func(w io.Writer, rep *report, eo *exportOptions) error {
+		header := []string{"date", "commits"}
+		var columns []*map[int]int
+		switch eo.columns {
+		case "repo":
+			for _, repo := range rep.repos {
+				commits := make(map[int]int)
+				countDailyCommits(repo.commits, rep.r, &commits)
+				header = append(header, repo.path)
+				columns = append(columns, &commits)
+			}
+		case "identity":
+			byEmail := make(map[string][]commitRecord)
+			var emails []string
+			for _, repo := range rep.repos {
+				for _, record := range repo.commits {
+					// no column for who only committed out of the range
+					if countDaysSinceDate(record.When, rep.r) == outOfRange {
+						continue
+					}
+					if _, ok := byEmail[record.Email]; !ok {
+						emails = append(emails, record.Email)
+					}
+					byEmail[record.Email] = append(byEmail[record.Email], record)
+				}
+			}
+			sort.Strings(emails)
+			for _, email := range emails {
+				commits := make(map[int]int)
+				countDailyCommits(byEmail[email], rep.r, &commits)
+				header = append(header, email)
+				columns = append(columns, &commits)
+			}
+		}
+
+		cw := csv.NewWriter(w)
+		cw.Comma = comma
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		commits := rep.dailyCommits()
+		keys := sortMapIntoSlice(commits)
+		for i := len(*keys) - 1; i >= 0; i-- {
+			k := (*keys)[i]
+			date := rep.r.until.AddDate(0, 0, -k)
+			row := []string{date.Format(dateFormat), strconv.Itoa((*commits)[k])}
+			for _, column := range columns {
+				row = append(row, strconv.Itoa((*column)[k]))
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// filterFlags holds the flags skipping the commits that are noise
+type filterFlags struct {
+	noMerges     bool
+	messages     patternList
+	generated    stringList
+	includePaths stringList
+	excludePaths stringList
+}
+
+// addFilterFlags defines the commit filter flags in `fs`
+func addFilterFlags(fs *flag.FlagSet) *filterFlags {
+	f := &filterFlags{}
+	fs.BoolVar(&f.noMerges, "no-merges", false, "skip the merge commits, having more than one parent")
+	fs.Var(&f.messages, "exclude-message", "skip the commits whose message matches the `regexp`, repeated for more (default from config)")
+	fs.Var(&f.generated, "generated", "skip the commits touching only the generated paths matching the `globs`, repeated or comma separated (default from config)")
+	fs.Var(&f.includePaths, "include-path", "only count the commits touching the paths matching the `globs`, besides the ones of the repository list")
+	fs.Var(&f.excludePaths, "exclude-path", "ignore the files matching the path `globs`, besides the ones of the repository list")
+	return f
+}
+
+// commitFilter skips the merges, the commits with excluded messages and
+// the ones touching only generated files
+type commitFilter struct {
+	noMerges  bool
+	messages  []*regexp.Regexp
+	generated []string
+	// paths applies to all the repositories, along with their own
+	paths pathFilter
+}
+
+// filter returns the commit filter selected by the flags, reading the
+// message patterns and the generated paths from the config when not passed
+func (f *filterFlags) filter() (*commitFilter, error) {
+	messages, generated := f.messages, f.generated
+	if len(messages) == 0 {
+		messages = configValues("exclude-message")
+	}
+	if len(generated) == 0 {
+		generated.Set(configValue("generated", ""))
+	}
+
+	cf := &commitFilter{
+		noMerges:  f.noMerges,
+		generated: generated,
+		paths:     pathFilter{include: f.includePaths, exclude: f.excludePaths},
+	}
+	for _, pattern := range messages {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -exclude-message %q: %v", pattern, err)
+		}
+		cf.messages = append(cf.messages, re)
+	}
+	for _, pattern := range generated {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid -generated %q: %v", pattern, err)
+		}
+	}
+	for _, pattern := range joinSlices(f.includePaths, f.excludePaths) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid path glob %q: %v", pattern, err)
+		}
+	}
+	return cf, nil
+}
+
+// skips returns true if the commit `c` is to be skipped without looking
+// at the files it touches
+func (cf *commitFilter) skips(c *object.Commit) bool {
+	if cf.noMerges && c.NumParents() > 1 {
+		return true
+	}
+	// without the trailing newline, for the patterns ending with $
+	message := strings.TrimRight(c.Message, "\n")
+	for _, re := range cf.messages {
+		if re.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyGenerated returns true if all the files changed, at least one,
+// match the generated paths
+func (cf *commitFilter) onlyGenerated(fileStats object.FileStats) bool {
+	if len(cf.generated) == 0 || len(fileStats) == 0 {
+		return false
+	}
+	for _, fs := range fileStats {
+		if !matchesAnyPath(cf.generated, fs.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the filter, for the cache key. The paths are
+// left out, being part of the path filter of each repository.
+func (cf *commitFilter) String() string {
+	var messages []string
+	for _, re := range cf.messages {
+		messages = append(messages, re.String())
+	}
+	return fmt.Sprintf("%v|%q|%q", cf.noMerges, messages, cf.generated)
+}
+
+// pathFilter selects the files whose changes are counted, in a monorepo
+type pathFilter struct {
+	include []string
+	exclude []string
+}
+
+// isEmpty returns true if the filter counts all the files
+func (pf pathFilter) isEmpty() bool {
+	return len(pf.include) == 0 && len(pf.exclude) == 0
+}
+
+// merge returns the filter with the globs of both `pf` and `other`
+func (pf pathFilter) merge(other pathFilter) pathFilter {
+	return pathFilter{
+		include: joinSlices(other.include, append([]string(nil), pf.include...)),
+		exclude: joinSlices(other.exclude, append([]string(nil), pf.exclude...)),
+	}
+}
+
+// includes returns true if the file in `name` is included, and not excluded
+func (pf pathFilter) includes(name string) bool {
+	if len(pf.include) > 0 && !matchesAnyPath(pf.include, name) {
+		return false
+	}
+	return !matchesAnyPath(pf.exclude, name)
+}
+
+// counts returns true if any of the files changed is included,
+// and not excluded
+func (pf pathFilter) counts(fileStats object.FileStats) bool {
+	for _, fs := range fileStats {
+		if pf.includes(fs.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the filter, for the cache key
+func (pf pathFilter) String() string {
+	return fmt.Sprintf("%q|%q", pf.include, pf.exclude)
+}
+
+// matchesAnyPath returns true if `filePath` matches any of the `patterns`.
+// A pattern with no slash matches any element of the path, like a name or
+// a folder, otherwise it matches the whole path or one of its parent folders.
+func matchesAnyPath(patterns []string, filePath string) bool {
+	elements := strings.Split(filePath, "/")
+	for _, pattern := range patterns {
+		pattern = strings.Trim(pattern, "/")
+		if !strings.Contains(pattern, "/") {
+			for _, element := range elements {
+				if ok, _ := path.Match(pattern, element); ok {
+					return true
+				}
+			}
+			continue
+		}
+		for i := range elements {
+			if ok, _ := path.Match(pattern, strings.Join(elements[:i+1], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
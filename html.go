@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+// htmlBar is a bar of an HTML histogram
+type htmlBar struct {
+	Label   string
+	Commits int
+	// Percent is the height of the bar, relative to the highest one
+	Percent int
+}
+
+// htmlRepository is a row of the repositories table of the HTML report
+type htmlRepository struct {
+	Path       string
+	Commits    int
+	ActiveDays int
+	// First and Last are the oldest and the newest commits in the time range
+	First time.Time
+	Last  time.Time
+}
+
+// htmlReport is the data rendered by htmlTemplate
+type htmlReport struct {
+	Since      string
+	Until      string
+	Identities []string
+	Commits    int
+	// Lines tells the lines added and removed are counted
+	Lines        bool
+	Additions    int
+	Deletions    int
+	Graph        template.HTML
+	Repositories []htmlRepository
+	Weekdays     []htmlBar
+	Hours        []htmlBar
+	Scanned      []string
+	Failures     []jsonFailure
+	Generated    string
+}
+
+// writeHTML writes a self-contained HTML page with the contributions graph,
+// the commits of each repository, the commits by weekday and by hour of the
+// day, and the list of the scanned repositories
+func writeHTML(w io.Writer, rep *report, eo *exportOptions) error {
+	var graph bytes.Buffer
+	if err := writeSVG(&graph, rep, eo); err != nil {
+		return err
+	}
+
+	out := htmlReport{
+		Since:      rep.r.since.Format(dateFormat),
+		Until:      rep.r.until.Format(dateFormat),
+		Identities: rep.identities(),
+		Graph:      template.HTML(graph.String()),
+		Generated:  time.Now().Format("2006-01-02 15:04"),
+	}
+
+	out.Repositories, out.Commits = repositoryRows(rep)
+	out.Lines = rep.lines
+	_, out.Additions, out.Deletions = rep.totals()
+
+	weekdays := make([]int, 7)
+	hours := make([]int, 24)
+	for day, dayHours := range rep.punchcard() {
+		for hour, commits := range dayHours {
+			weekdays[day] += commits
+			hours[hour] += commits
+		}
+	}
+	for _, repo := range rep.repos {
+		out.Scanned = append(out.Scanned, repo.path)
+	}
+	for _, f := range rep.failures {
+		out.Failures = append(out.Failures, jsonFailure{Path: f.path, Error: f.err.Error()})
+		out.Scanned = append(out.Scanned, f.path)
+	}
+
+	for day, commits := range weekdays {
+		out.Weekdays = append(out.Weekdays, htmlBar{Label: time.Weekday(day).String()[:3], Commits: commits})
+	}
+	for hour, commits := range hours {
+		out.Hours = append(out.Hours, htmlBar{Label: fmt.Sprintf("%02d", hour), Commits: commits})
+	}
+	scaleBars(out.Weekdays)
+	scaleBars(out.Hours)
+
+	return htmlTemplate.Execute(w, out)
+}
+
+// repositoryRows returns the commits and the active days of each
+// repository in the time range, most commits first, and the total commits
+func repositoryRows(rep *report) ([]htmlRepository, int) {
+	var rows []htmlRepository
+	total := 0
+	for _, repo := range rep.repos {
+		row := htmlRepository{Path: repo.path}
+		days := make(map[int]bool)
+		for _, record := range repo.commits {
+			daysAgo := countDaysSinceDate(record.When, rep.r)
+			if daysAgo == outOfRange {
+				continue
+			}
+			row.Commits++
+			days[daysAgo] = true
+			if row.First.IsZero() || record.When.Before(row.First) {
+				row.First = record.When
+			}
+			if record.When.After(row.Last) {
+				row.Last = record.When
+			}
+		}
+		row.ActiveDays = len(days)
+		total += row.Commits
+		rows = append(rows, row)
+	}
+	// in the dot file order for the same commits
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Commits > rows[j].Commits
+	})
+	return rows, total
+}
+
+// scaleBars sets the height of the bars relative to the highest one
+func scaleBars(bars []htmlBar) {
+	max := 0
+	for _, bar := range bars {
+		if bar.Commits > max {
+			max = bar.Commits
+		}
+	}
+	if max == 0 {
+		return
+	}
+	for i := range bars {
+		bars[i].Percent = bars[i].Commits * 100 / max
+	}
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{"commits": commitsLabel}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Git contributions {{.Since}} – {{.Until}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292e; margin: 2em auto; max-width: 960px; padding: 0 1em; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.1em; margin-top: 2em; border-bottom: 1px solid #eaecef; padding-bottom: .3em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .3em .6em; border-bottom: 1px solid #eaecef; }
+td.num, th.num { text-align: right; }
+.meta { color: #586069; }
+.histogram { display: flex; align-items: flex-end; height: 120px; gap: 4px; }
+.histogram div { flex: 1; display: flex; flex-direction: column; justify-content: flex-end; height: 100%; text-align: center; font-size: 11px; color: #586069; }
+.histogram span.bar { display: block; background: #40c463; border-radius: 2px 2px 0 0; }
+.failed { color: #cb2431; }
+</style>
+</head>
+<body>
+<h1>Git contributions</h1>
+<p class="meta">From {{.Since}} to {{.Until}}, {{.Commits}} commits by {{range $i, $id := .Identities}}{{if $i}}, {{end}}{{$id}}{{end}}{{if .Lines}}, {{.Additions}} lines added and {{.Deletions}} removed{{end}}.</p>
+
+{{.Graph}}
+
+<h2>Repositories</h2>
+<table>
+<tr><th>Repository</th><th class="num">Commits</th><th class="num">Active days</th></tr>
+{{range .Repositories}}<tr><td>{{.Path}}</td><td class="num">{{.Commits}}</td><td class="num">{{.ActiveDays}}</td></tr>
+{{end}}</table>
+
+<h2>Commits by weekday</h2>
+<div class="histogram">
+{{range .Weekdays}}<div title="{{commits .Commits}}"><span class="bar" style="height: {{.Percent}}%"></span>{{.Label}}</div>
+{{end}}</div>
+
+<h2>Commits by hour</h2>
+<div class="histogram">
+{{range .Hours}}<div title="{{commits .Commits}}"><span class="bar" style="height: {{.Percent}}%"></span>{{.Label}}</div>
+{{end}}</div>
+
+<h2>Scanned repositories</h2>
+<ul>
+{{range .Scanned}}<li>{{.}}</li>
+{{end}}</ul>
+{{if .Failures}}<p class="failed">Skipped because of errors:</p>
+<ul class="failed">
+{{range .Failures}}<li>{{.Path}}: {{.Error}}</li>
+{{end}}</ul>
+{{end}}
+<p class="meta">Generated by gogitlocalstats on {{.Generated}}.</p>
+</body>
+</html>
+`))
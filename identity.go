@@ -0,0 +1,208 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	gitconfig "gopkg.in/src-d/go-git.v4/plumbing/format/config"
+)
+
+// identity is the set of emails and name patterns a user commits with
+type identity struct {
+	emails   []string
+	patterns []*regexp.Regexp
+	// repoConfig adds the user.email of each repository config
+	repoConfig bool
+}
+
+// newIdentity builds an identity from a list of emails and a list of
+// author patterns. A pattern is a glob (`*` and `?`), or a regular
+// expression when prefixed by `re:`, and matches both names and emails.
+func newIdentity(emails []string, patterns []string) (*identity, error) {
+	id := &identity{}
+	for _, email := range emails {
+		id.emails = append(id.emails, strings.ToLower(email))
+	}
+	for _, pattern := range patterns {
+		expr := globToRegexp(pattern)
+		if strings.HasPrefix(pattern, "re:") {
+			expr = strings.TrimPrefix(pattern, "re:")
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid author pattern %q: %v", pattern, err)
+		}
+		id.patterns = append(id.patterns, re)
+	}
+	return id, nil
+}
+
+// globToRegexp converts a glob to an anchored regular expression
+func globToRegexp(glob string) string {
+	expr := regexp.QuoteMeta(glob)
+	expr = strings.Replace(expr, `\*`, ".*", -1)
+	expr = strings.Replace(expr, `\?`, ".", -1)
+	return "^" + expr + "$"
+}
+
+// isEmpty returns true if the identity can't match any commit
+func (id *identity) isEmpty() bool {
+	return len(id.emails) == 0 && len(id.patterns) == 0
+}
+
+// matches returns true if the author `name` and `email` belong to the identity
+func (id *identity) matches(name string, email string) bool {
+	if sliceContains(id.emails, strings.ToLower(email)) {
+		return true
+	}
+	for _, re := range id.patterns {
+		if re.MatchString(name) || re.MatchString(email) {
+			return true
+		}
+	}
+	return false
+}
+
+// withMailmap returns a copy of the identity also matching the canonical
+// emails `mm` maps its emails to, so all the aliases of a user are counted
+func (id *identity) withMailmap(mm *mailmap) *identity {
+	res := &identity{emails: id.emails, patterns: id.patterns, repoConfig: id.repoConfig}
+	for _, email := range id.emails {
+		_, canonical := mm.resolve("", email)
+		res.emails = joinSlices([]string{strings.ToLower(canonical)}, res.emails)
+	}
+	return res
+}
+
+// forRepository returns the identity to match in the repository found
+// in `path`, adding the user.email of its .git/config when the identity
+// was resolved from the git config
+func (id *identity) forRepository(path string) *identity {
+	if !id.repoConfig {
+		return id
+	}
+	email := gitConfigEmail(path + "/.git/config")
+	if email == "" {
+		return id
+	}
+	res := &identity{emails: id.emails, patterns: id.patterns, repoConfig: id.repoConfig}
+	res.emails = joinSlices([]string{strings.ToLower(email)}, res.emails)
+	return res
+}
+
+// gitConfigEmail returns the user.email set in the git config file
+// found in `filePath`, or an empty string if it's not set
+func gitConfigEmail(filePath string) string {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	cfg := gitconfig.New()
+	if err := gitconfig.NewDecoder(f).Decode(cfg); err != nil {
+		return ""
+	}
+	return cfg.Section("user").Option("email")
+}
+
+// globalGitConfigEmail returns the user.email set in the global
+// ~/.gitconfig file, or in $XDG_CONFIG_HOME/git/config
+func globalGitConfigEmail() string {
+	if email := gitConfigEmail(getHomeDir() + "/.gitconfig"); email != "" {
+		return email
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = getHomeDir() + "/.config"
+	}
+	return gitConfigEmail(configHome + "/git/config")
+}
+
+// list returns the emails and patterns of the identity
+func (id *identity) list() []string {
+	parts := append([]string{}, id.emails...)
+	for _, re := range id.patterns {
+		parts = append(parts, strings.TrimPrefix(re.String(), "(?i)"))
+	}
+	return parts
+}
+
+// String returns the emails and patterns of the identity
+func (id *identity) String() string {
+	return strings.Join(id.list(), ", ")
+}
+
+// stringList is a flag accepting multiple values, either repeating
+// the flag or separating the values with commas
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// patternList is a flag accepting multiple patterns by repeating the
+// flag. Unlike stringList the values aren't split on commas, which are
+// part of regexps like a{1,3}.
+type patternList []string
+
+func (l *patternList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *patternList) Set(value string) error {
+	if value = strings.TrimSpace(value); value != "" {
+		*l = append(*l, value)
+	}
+	return nil
+}
+
+// identityFlags holds the flags used to select the identity
+type identityFlags struct {
+	emails  stringList
+	authors patternList
+}
+
+// addIdentityFlags defines the identity flags in `fs`
+func addIdentityFlags(fs *flag.FlagSet) *identityFlags {
+	f := &identityFlags{}
+	fs.Var(&f.emails, "email", "the `emails` to scan, repeated or comma separated (default from config)")
+	fs.Var(&f.authors, "author", "author name or email `pattern`, as a glob or as a regexp prefixed by re:, repeated for more (default from config)")
+	return f
+}
+
+// identity returns the identity selected by the flags, falling back
+// to the configured emails and authors when no flag is passed, and then
+// to the user.email of the global and of each repository git config
+func (f *identityFlags) identity() (*identity, error) {
+	emails, authors := f.emails, f.authors
+	if len(emails) > 0 || len(authors) > 0 {
+		return newIdentity(emails, authors)
+	}
+
+	emails.Set(configValue("email", ""))
+	authors = configValues("author")
+	if len(emails) > 0 || len(authors) > 0 {
+		return newIdentity(emails, authors)
+	}
+
+	emails.Set(globalGitConfigEmail())
+	id, err := newIdentity(emails, authors)
+	if err != nil {
+		return nil, err
+	}
+	id.repoConfig = true
+	return id, nil
+}
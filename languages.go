@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// otherLanguage is the language of the files with no known extension
+const otherLanguage = "Other"
+
+// languageExtensions maps the file extensions to their languages
+var languageExtensions = map[string]string{
+	".go":       "Go",
+	".ts":       "TypeScript",
+	".tsx":      "TypeScript",
+	".js":       "JavaScript",
+	".jsx":      "JavaScript",
+	".mjs":      "JavaScript",
+	".cjs":      "JavaScript",
+	".vue":      "Vue",
+	".svelte":   "Svelte",
+	".html":     "HTML",
+	".htm":      "HTML",
+	".css":      "CSS",
+	".scss":     "SCSS",
+	".sass":     "SCSS",
+	".less":     "Less",
+	".py":       "Python",
+	".rb":       "Ruby",
+	".php":      "PHP",
+	".java":     "Java",
+	".kt":       "Kotlin",
+	".kts":      "Kotlin",
+	".scala":    "Scala",
+	".swift":    "Swift",
+	".m":        "Objective-C",
+	".c":        "C",
+	".h":        "C",
+	".cc":       "C++",
+	".cpp":      "C++",
+	".cxx":      "C++",
+	".hpp":      "C++",
+	".cs":       "C#",
+	".rs":       "Rust",
+	".ex":       "Elixir",
+	".exs":      "Elixir",
+	".erl":      "Erlang",
+	".hs":       "Haskell",
+	".clj":      "Clojure",
+	".dart":     "Dart",
+	".lua":      "Lua",
+	".pl":       "Perl",
+	".r":        "R",
+	".sql":      "SQL",
+	".sh":       "Shell",
+	".bash":     "Shell",
+	".zsh":      "Shell",
+	".ps1":      "PowerShell",
+	".proto":    "Protocol Buffers",
+	".md":       "Markdown",
+	".markdown": "Markdown",
+	".rst":      "reStructuredText",
+	".txt":      "Text",
+	".json":     "JSON",
+	".yml":      "YAML",
+	".yaml":     "YAML",
+	".toml":     "TOML",
+	".xml":      "XML",
+	".tf":       "Terraform",
+}
+
+// languageFilenames maps the files known by their name to their languages
+var languageFilenames = map[string]string{
+	"makefile":       "Makefile",
+	"gnumakefile":    "Makefile",
+	"dockerfile":     "Dockerfile",
+	"rakefile":       "Ruby",
+	"gemfile":        "Ruby",
+	"cmakelists.txt": "CMake",
+}
+
+// languageOf returns the language of the file in `filePath`,
+// by its name or its extension
+func languageOf(filePath string) string {
+	name := strings.ToLower(path.Base(filePath))
+	if lang, ok := languageFilenames[name]; ok {
+		return lang
+	}
+	if lang, ok := languageExtensions[path.Ext(name)]; ok {
+		return lang
+	}
+	return otherLanguage
+}
+
+// languageStats holds the commits touching the files of a language,
+// and the lines changed in them
+type languageStats struct {
+	language string
+	commits  int
+	lines    int
+}
+
+// languages returns the commits and the lines changed of each language
+// in the time range, most lines changed first
+func (rep *report) languages() []languageStats {
+	byLanguage := make(map[string]*languageStats)
+	for _, repo := range rep.repos {
+		for _, record := range repo.commits {
+			if countDaysSinceDate(record.When, rep.r) == outOfRange {
+				continue
+			}
+			for lang, lines := range record.Languages {
+				stats, ok := byLanguage[lang]
+				if !ok {
+					stats = &languageStats{language: lang}
+					byLanguage[lang] = stats
+				}
+				stats.commits++
+				stats.lines += lines
+			}
+		}
+	}
+
+	var out []languageStats
+	for _, stats := range byLanguage {
+		out = append(out, *stats)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].lines != out[j].lines {
+			return out[i].lines > out[j].lines
+		}
+		if out[i].commits != out[j].commits {
+			return out[i].commits > out[j].commits
+		}
+		return out[i].language < out[j].language
+	})
+	return out
+}
+
+// printLanguages prints a table of the languages, most lines changed
+// first, with the commits touching them and the lines changed. A commit
+// touching several languages counts for each of them.
+func printLanguages(rep *report) {
+	languages := rep.languages()
+	total := 0
+	for _, stats := range languages {
+		total += stats.lines
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "COMMITS\tLINES\tSHARE\t  LANGUAGE")
+	for _, stats := range languages {
+		share := 0
+		if total > 0 {
+			share = stats.lines * 100 / total
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d%%\t  %s\n", stats.commits, stats.lines, share, stats.language)
+	}
+	tw.Flush()
+}
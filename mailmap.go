@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// mailmapEntry is a line of a .mailmap file. Commits matching
+// `commitEmail`, and `commitName` if set, are attributed to
+// `properName` and `properEmail`, when set.
+type mailmapEntry struct {
+	properName  string
+	properEmail string
+	commitName  string
+	commitEmail string
+}
+
+// mailmap maps the names and emails found in the commits
+// to the canonical ones, as described by a .mailmap file
+type mailmap struct {
+	entries []mailmapEntry
+}
+
+// readMailmap parses the .mailmap file in the repository found in `path`.
+// Returns an empty mailmap if the repository has no .mailmap file.
+func readMailmap(path string) (*mailmap, error) {
+	mm := &mailmap{}
+	// just read, the repository may belong to another user
+	content, err := ioutil.ReadFile(path + "/.mailmap")
+	if os.IsNotExist(err) {
+		return mm, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if entry, ok := parseMailmapLine(line); ok {
+			mm.entries = append(mm.entries, entry)
+		}
+	}
+
+	return mm, nil
+}
+
+// parseMailmapLine parses a line in one of the forms
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+//
+// returning false for comments, empty or invalid lines
+func parseMailmapLine(line string) (mailmapEntry, bool) {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+
+	var names, emails []string
+	for len(emails) < 2 {
+		start := strings.Index(line, "<")
+		end := strings.Index(line, ">")
+		if start < 0 || end < start {
+			break
+		}
+		names = append(names, strings.TrimSpace(line[:start]))
+		emails = append(emails, strings.TrimSpace(line[start+1:end]))
+		line = line[end+1:]
+	}
+
+	switch len(emails) {
+	case 1:
+		if names[0] == "" {
+			return mailmapEntry{}, false
+		}
+		return mailmapEntry{properName: names[0], commitEmail: emails[0]}, true
+	case 2:
+		return mailmapEntry{
+			properName:  names[0],
+			properEmail: emails[0],
+			commitName:  names[1],
+			commitEmail: emails[1],
+		}, true
+	}
+	return mailmapEntry{}, false
+}
+
+// resolve returns the canonical name and email for a commit signed
+// with `name` and `email`. An entry matching both name and email takes
+// precedence over the ones matching just the email.
+func (mm *mailmap) resolve(name string, email string) (string, string) {
+	var matches []mailmapEntry
+	for _, entry := range mm.entries {
+		if !strings.EqualFold(entry.commitEmail, email) {
+			continue
+		}
+		if entry.commitName == "" {
+			matches = append(matches, entry)
+			continue
+		}
+		if strings.EqualFold(entry.commitName, name) {
+			matches = []mailmapEntry{entry}
+			break
+		}
+	}
+
+	for _, entry := range matches {
+		if entry.properName != "" {
+			name = entry.properName
+		}
+		if entry.properEmail != "" {
+			email = entry.properEmail
+		}
+	}
+	return name, email
+}
@@ -3,26 +3,114 @@ package main
 import (
 	"flag"
 	"fmt"
-	"time"
+	"io"
+	"os"
+	"strings"
 )
 
+// exit codes returned by the commands
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// command is a gogitlocalstats subcommand, run with its own arguments
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(cmd *command, args []string) int
+}
+
+var commands = []*command{
+	{"scan", "<folder>...", "scan folders for Git repositories and add them to the list", runScan},
+	{"stats", "", "print the contributions graph", runStats},
+	{"repos", "list|remove", "list or remove the tracked repositories", runRepos},
+	{"config", "[key [value...]]", "get or set the configuration values", runConfig},
+	{"export", "", "export the daily commit counts", runExport},
+	{"punchcard", "", "print the commits by weekday and hour of the day", runPunchcard},
+	{"serve", "", "serve a web dashboard, a JSON API and Prometheus metrics of the stats", runServe},
+}
+
 func main() {
-	startingTime := time.Now().UTC()
+	os.Exit(run(os.Args[1:]))
+}
 
-	var folder string
-	var email string
-	flag.StringVar(&folder, "add", "", "add a new folder to scan for Git repositories")
-	flag.StringVar(&email, "email", "copesc@gmail.com", "the email to scan")
-	flag.Parse()
+// run dispatches `args` to the matching command and returns the exit code.
+// Without a command, or with just flags, the stats are printed.
+func run(args []string) int {
+	if len(args) > 0 && isHelp(args[0]) {
+		usage(os.Stdout, "gogitlocalstats", commands)
+		return exitOK
+	}
+
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runStats(findCommand(commands, "stats"), args)
+	}
+
+	cmd := findCommand(commands, args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		usage(os.Stderr, "gogitlocalstats", commands)
+		return exitUsage
+	}
+
+	return cmd.run(cmd, args[1:])
+}
 
-	if folder != "" {
-		scan(folder)
-		endingTime := time.Now().UTC()
-		fmt.Println(endingTime.Sub(startingTime))
-		return
+// findCommand returns the command called `name`, or nil if there's none
+func findCommand(cmds []*command, name string) *command {
+	for _, cmd := range cmds {
+		if cmd.name == name {
+			return cmd
+		}
 	}
+	return nil
+}
+
+// isHelp returns true if `arg` asks for the usage message
+func isHelp(arg string) bool {
+	return arg == "help" || arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// usage prints the list of the commands available to `prog`
+func usage(w io.Writer, prog string, cmds []*command) {
+	fmt.Fprintf(w, "usage: %s <command> [flags] [args]\n\ncommands:\n", prog)
+	for _, cmd := range cmds {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nrun '%s <command> -h' for the command flags\n", prog)
+}
+
+// flagSet returns a new flag set for the command, printing the command
+// usage on -h or on a parsing error
+func (cmd *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		synopsis := strings.TrimSpace("gogitlocalstats " + cmd.name + " [flags] " + cmd.args)
+		fmt.Fprintf(fs.Output(), "usage: %s\n\n%s\n\nflags:\n", synopsis, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses `args` with `fs`. When the command must not go on,
+// it returns false along with the exit code.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	err := fs.Parse(args)
+	if err == flag.ErrHelp {
+		return exitOK, false
+	}
+	if err != nil {
+		return exitUsage, false
+	}
+	return exitOK, true
+}
 
-	stats(email)
-	endingTime := time.Now().UTC()
-	fmt.Println(endingTime.Sub(startingTime))
+// usageError prints `msg` and the command usage, returning the usage exit code
+func usageError(fs *flag.FlagSet, msg string) int {
+	fmt.Fprintf(fs.Output(), "%s\n\n", msg)
+	fs.Usage()
+	return exitUsage
 }
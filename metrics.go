@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// metricsLabelEscaper escapes the label values of the Prometheus text format
+var metricsLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeMetrics writes the metrics snapshot to `w` in the Prometheus text
+// exposition format: the commits of each repository in the time range and
+// on its last day, the amount of tracked repositories, how long the scan
+// took and when, and the repositories that couldn't be processed. The
+// history of the days is left to Prometheus.
+func writeMetrics(w io.Writer, snap *metricsSnapshot) error {
+	rep := snap.rep
+	ew := &errWriter{w: w}
+
+	ew.printf("# HELP gogitlocalstats_commits Commits made in a repository in the time range.\n")
+	ew.printf("# TYPE gogitlocalstats_commits gauge\n")
+	rows, _ := repositoryRows(rep)
+	for _, row := range rows {
+		ew.printf("gogitlocalstats_commits{repository=\"%s\"} %d\n", metricsLabelEscaper.Replace(row.Path), row.Commits)
+	}
+
+	ew.printf("# HELP gogitlocalstats_commits_today Commits made in a repository on the last day of the time range, today by default.\n")
+	ew.printf("# TYPE gogitlocalstats_commits_today gauge\n")
+	for _, repo := range rep.repos {
+		today := 0
+		for _, record := range repo.commits {
+			if countDaysSinceDate(record.When, rep.r) == 0 {
+				today++
+			}
+		}
+		ew.printf("gogitlocalstats_commits_today{repository=\"%s\"} %d\n", metricsLabelEscaper.Replace(repo.path), today)
+	}
+
+	ew.printf("# HELP gogitlocalstats_repositories Repositories in the list of tracked repositories.\n")
+	ew.printf("# TYPE gogitlocalstats_repositories gauge\n")
+	ew.printf("gogitlocalstats_repositories %d\n", snap.tracked)
+
+	ew.printf("# HELP gogitlocalstats_scan_duration_seconds Time taken to calculate the stats.\n")
+	ew.printf("# TYPE gogitlocalstats_scan_duration_seconds gauge\n")
+	ew.printf("gogitlocalstats_scan_duration_seconds %g\n", snap.duration.Seconds())
+
+	ew.printf("# HELP gogitlocalstats_scan_timestamp_seconds When the stats were calculated.\n")
+	ew.printf("# TYPE gogitlocalstats_scan_timestamp_seconds gauge\n")
+	ew.printf("gogitlocalstats_scan_timestamp_seconds %d\n", snap.at.Unix())
+
+	ew.printf("# HELP gogitlocalstats_failures Repositories that couldn't be processed.\n")
+	ew.printf("# TYPE gogitlocalstats_failures gauge\n")
+	ew.printf("gogitlocalstats_failures %d\n", len(rep.failures))
+
+	ew.printf("# HELP gogitlocalstats_repository_failed Whether a repository couldn't be processed.\n")
+	ew.printf("# TYPE gogitlocalstats_repository_failed gauge\n")
+	for _, f := range rep.failures {
+		ew.printf("gogitlocalstats_repository_failed{repository=\"%s\"} 1\n", metricsLabelEscaper.Replace(f.path))
+	}
+
+	return ew.err
+}
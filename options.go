@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// statsOptions holds the options used to calculate the stats
+type statsOptions struct {
+	id   *identity
+	r    timeRange
+	jobs int
+	// the refs walked besides HEAD
+	allRefs bool
+	remotes bool
+	tags    bool
+	// strict makes the commands fail when a repository can't be processed
+	strict bool
+	cache  bool
+	// readOnlyCache uses the cache files without writing new ones
+	readOnlyCache bool
+	// lines counts the lines added and removed by each commit
+	lines bool
+	// date is the date the commits are counted on, "author" or "committer"
+	date string
+	// match is who the identity is matched against, "author",
+	// "committer" or "either"
+	match  string
+	filter *commitFilter
+	// repos limits the stats to the repositories matching these globs
+	repos []string
+}
+
+// statsFlags holds the flags shared by the commands calculating the stats
+type statsFlags struct {
+	identity  *identityFlags
+	timeRange *rangeFlags
+	filter    *filterFlags
+	jobs      int
+	allRefs   bool
+	remotes   bool
+	tags      bool
+	strict    bool
+	noCache   bool
+	lines     bool
+	date      string
+	match     string
+	repos     stringList
+}
+
+// addStatsFlags defines the stats flags in `fs`
+func addStatsFlags(fs *flag.FlagSet) *statsFlags {
+	f := &statsFlags{
+		identity:  addIdentityFlags(fs),
+		timeRange: addRangeFlags(fs),
+		filter:    addFilterFlags(fs),
+	}
+	fs.IntVar(&f.jobs, "jobs", runtime.NumCPU(), "process `N` repositories in parallel")
+	fs.BoolVar(&f.allRefs, "all-refs", false, "count the commits of all the local branches, not just HEAD")
+	fs.BoolVar(&f.remotes, "remotes", false, "also count the commits of the remote-tracking branches (implies -all-refs)")
+	fs.BoolVar(&f.tags, "tags", false, "also count the commits of the tags (implies -all-refs)")
+	fs.BoolVar(&f.strict, "strict", false, "exit with an error if any repository can't be processed")
+	fs.BoolVar(&f.noCache, "no-cache", false, "walk the whole history instead of using the cache")
+	fs.BoolVar(&f.lines, "lines", false, "count the lines added and removed, coloring the graph by lines changed")
+	fs.StringVar(&f.date, "date", "author", "count the commits on the `author` or on the committer date")
+	fs.StringVar(&f.match, "match", "author", "match the identity against the `author`, the committer, or either")
+	fs.Var(&f.repos, "repo", "only count the repositories matching the path `globs`, repeated or comma separated")
+	return f
+}
+
+// options returns the stats options selected by the flags, relative to `now`
+func (f *statsFlags) options(now time.Time) (*statsOptions, error) {
+	r, err := f.timeRange.timeRange(now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := f.identity.identity()
+	if err != nil {
+		return nil, err
+	}
+	filter, err := f.filter.filter()
+	if err != nil {
+		return nil, err
+	}
+	if f.jobs < 1 {
+		return nil, fmt.Errorf("-jobs must be at least 1")
+	}
+	if f.date != "author" && f.date != "committer" {
+		return nil, fmt.Errorf("-date must be author or committer")
+	}
+	if f.match != "author" && f.match != "committer" && f.match != "either" {
+		return nil, fmt.Errorf("-match must be author, committer or either")
+	}
+
+	return &statsOptions{
+		id:      id,
+		r:       r,
+		jobs:    f.jobs,
+		allRefs: f.allRefs || f.remotes || f.tags,
+		remotes: f.remotes,
+		tags:    f.tags,
+		strict:  f.strict,
+		cache:   !f.noCache,
+		lines:   f.lines,
+		date:    f.date,
+		match:   f.match,
+		filter:  filter,
+		repos:   f.repos,
+	}, nil
+}
+
+// includesRepo returns true if the repository in `path` passes the -repo filter
+func (opts *statsOptions) includesRepo(path string) bool {
+	if len(opts.repos) == 0 {
+		return true
+	}
+	for _, pattern := range opts.repos {
+		if ok, _ := filepath.Match(strings.TrimSuffix(pattern, "/"), path); ok {
+			return true
+		}
+	}
+	return false
+}
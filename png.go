@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// writePNG writes the contributions graph as a PNG image, with the same
+// grid as writeSVG. Having no font to draw with, there are no labels:
+// just the cells and, below them, the legend of the colors.
+func writePNG(w io.Writer, rep *report, eo *exportOptions) error {
+	palette := make([]color.RGBA, len(eo.palette))
+	for i, hex := range eo.palette {
+		c, err := parseHexColor(hex)
+		if err != nil {
+			return err
+		}
+		palette[i] = c
+	}
+
+	hm := rep.heatmap()
+	keys := sortMapIntoSlice(hm.values)
+	cols := buildCols(keys, hm.values, rep.r)
+	weeks := rep.r.weeks()
+	step := eo.cellSize + eo.cellGap
+	margin := eo.cellSize
+	width := 2*margin + weeks*step - eo.cellGap
+	height := 2*margin + 7*step - eo.cellGap
+	if eo.legend {
+		height += step + eo.cellSize
+		// a short range still fits the whole legend
+		if legendWidth := 2*margin + len(palette)*step - eo.cellGap; width < legendWidth {
+			width = legendWidth
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	drawCell := func(x, y int, c color.RGBA) {
+		cell := image.Rect(x, y, x+eo.cellSize, y+eo.cellSize)
+		draw.Draw(img, cell, &image.Uniform{c}, image.Point{}, draw.Src)
+	}
+
+	for week := weeks - 1; week >= 0; week-- {
+		x := weeks - 1 - week
+		for day, val := range (*cols)[week] {
+			if val == empty {
+				continue
+			}
+			drawCell(margin+x*step, margin+day*step, palette[cellLevel(val, hm.levels)])
+		}
+	}
+
+	// the legend goes from no commits to the most, aligned to the right
+	if eo.legend {
+		y := margin + 7*step + eo.cellSize
+		for i, c := range palette {
+			drawCell(width-margin-(len(palette)-i)*step+eo.cellGap, y, c)
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// parseHexColor parses a #rrggbb or #rgb color
+func parseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected #rrggbb", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
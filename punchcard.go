@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// punchcardDots are the cells of the punch card, from no commits to the most
+var punchcardDots = []string{" ", "·", "•", "●"}
+
+// punchcard returns the commits in the time range by weekday, Sunday
+// first, and by hour of the day, in the timezone of the commit date
+func (rep *report) punchcard() [7][24]int {
+	var card [7][24]int
+	for _, repo := range rep.repos {
+		for _, record := range repo.commits {
+			if countDaysSinceDate(record.When, rep.r) == outOfRange {
+				continue
+			}
+			// in the author timezone, when the author was working,
+			// or in the committer one with -date committer
+			card[record.When.Weekday()][record.When.Hour()]++
+		}
+	}
+	return card
+}
+
+// printPunchcard prints the commits by weekday and by hour of the day,
+// a row for each weekday and a column for each hour, the size of the
+// dots relative to the busiest hour. Each row ends with the commits of
+// the weekday.
+func printPunchcard(rep *report) {
+	card := rep.punchcard()
+	max := 0
+	for _, hours := range card {
+		for _, val := range hours {
+			if val > max {
+				max = val
+			}
+		}
+	}
+
+	fmt.Printf("    ")
+	for hour := 0; hour < 24; hour++ {
+		fmt.Printf("%3d", hour)
+	}
+	fmt.Printf("\n")
+	for day, hours := range card {
+		fmt.Printf("%s ", time.Weekday(day).String()[:3])
+		total := 0
+		for _, val := range hours {
+			level := 0
+			if val > 0 {
+				// 1 to 3, the busiest hours being 3
+				level = 1 + (val*3-1)/max
+			}
+			fmt.Printf("  %s", punchcardDots[level])
+			total += val
+		}
+		fmt.Printf("  %d\n", total)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"container/heap"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// startingCommits returns the commits the history walk starts from:
+// HEAD and, with `opts.allRefs`, the local branches, plus the
+// remote-tracking branches and the tags when asked to
+func startingCommits(repo *git.Repository, opts *statsOptions) ([]*object.Commit, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	hashes := []plumbing.Hash{head.Hash()}
+
+	if opts.allRefs {
+		refs, err := repo.References()
+		if err != nil {
+			return nil, err
+		}
+		err = refs.ForEach(func(ref *plumbing.Reference) error {
+			if ref.Type() != plumbing.HashReference {
+				return nil
+			}
+			name := ref.Name()
+			switch {
+			case name.IsBranch(), name.IsRemote() && opts.remotes:
+				hashes = append(hashes, ref.Hash())
+			case name.IsTag() && opts.tags:
+				// annotated tags point to a tag object, not to the commit
+				if tag, err := repo.TagObject(ref.Hash()); err == nil {
+					if c, err := tag.Commit(); err == nil {
+						hashes = append(hashes, c.Hash)
+					}
+					return nil
+				}
+				hashes = append(hashes, ref.Hash())
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var commits []*object.Commit
+	seen := make(map[plumbing.Hash]bool)
+	for _, h := range hashes {
+		if seen[h] {
+			continue
+		}
+		seen[h] = true
+		c, err := repo.CommitObject(h)
+		if err != nil {
+			// lightweight tags can point to trees or blobs
+			if err == plumbing.ErrObjectNotFound {
+				continue
+			}
+			return nil, err
+		}
+		commits = append(commits, c)
+	}
+
+	return commits, nil
+}
+
+// queuedCommit is a commit waiting to be walked by walkCommits
+type queuedCommit struct {
+	c             *object.Commit
+	uninteresting bool
+}
+
+// commitQueue is a heap of commits, the most recently committed first
+type commitQueue []*queuedCommit
+
+func (q commitQueue) Len() int { return len(q) }
+func (q commitQueue) Less(i, j int) bool {
+	return q[i].c.Committer.When.After(q[j].c.Committer.When)
+}
+func (q commitQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x interface{}) { *q = append(*q, x.(*queuedCommit)) }
+func (q *commitQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// walkCommits calls `fn` once for each commit reachable from `tips` but not
+// from `exclude`, newest first by committer time, like `git rev-list tips
+// ^exclude`. The walk ends when `fn` returns storer.ErrStop.
+// Returns the excluded commits reached while walking from `tips`.
+func walkCommits(tips []*object.Commit, exclude []*object.Commit, fn func(*object.Commit) error) (map[plumbing.Hash]bool, error) {
+	q := &commitQueue{}
+	uninteresting := make(map[plumbing.Hash]bool)
+	queued := make(map[plumbing.Hash]bool)
+	reached := make(map[plumbing.Hash]bool)
+	interesting := 0
+
+	push := func(c *object.Commit, isUninteresting bool) {
+		if uninteresting[c.Hash] {
+			if !isUninteresting {
+				reached[c.Hash] = true
+			}
+			return
+		}
+		if isUninteresting {
+			uninteresting[c.Hash] = true
+		} else {
+			if queued[c.Hash] {
+				return
+			}
+			queued[c.Hash] = true
+			interesting++
+		}
+		heap.Push(q, &queuedCommit{c: c, uninteresting: isUninteresting})
+	}
+
+	for _, c := range exclude {
+		push(c, true)
+	}
+	for _, c := range tips {
+		push(c, false)
+	}
+
+	// once only uninteresting commits are left, there's nothing to walk
+	for interesting > 0 {
+		item := heap.Pop(q).(*queuedCommit)
+		if !item.uninteresting {
+			interesting--
+			// reached from `exclude` after being queued
+			if uninteresting[item.c.Hash] {
+				continue
+			}
+			if err := fn(item.c); err != nil {
+				if err == storer.ErrStop {
+					return reached, nil
+				}
+				return nil, err
+			}
+		}
+
+		err := item.c.Parents().ForEach(func(p *object.Commit) error {
+			push(p, item.uninteresting)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return reached, nil
+}
@@ -8,45 +8,52 @@ import (
 	"log"
 	"os"
 	"os/user"
+	"regexp"
 	"strings"
 )
 
-// getDotFilePath returns the dot file for the repos list.
-// Creates it and the enclosing folder if it does not exist.
-func getDotFilePath() string {
+// getHomeDir returns the home folder of the current user
+func getHomeDir() string {
 	usr, err := user.Current()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	dotFile := usr.HomeDir + "/.gogitlocalstats"
+	return usr.HomeDir
+}
+
+// getDotFilePath returns the dot file for the repos list.
+// Creates it and the enclosing folder if it does not exist.
+func getDotFilePath() string {
+	dotFile := getHomeDir() + "/.gogitlocalstats"
 
 	return dotFile
 }
 
+// folderExists returns true if `path` exists and is a folder
+func folderExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
 // openFile opens the file located at `filePath`. Creates it if not existing.
-func openFile(filePath string) *os.File {
+func openFile(filePath string) (*os.File, error) {
 	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_RDWR, 0755)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// file does not exist
-			_, err = os.Create(filePath)
-			if err != nil {
-				panic(err)
-			}
-		} else {
-			// other error
-			panic(err)
-		}
+	if os.IsNotExist(err) {
+		// file does not exist
+		return os.Create(filePath)
 	}
 
-	return f
+	return f, err
 }
 
 // parseFileLinesToSlice given a file path string, gets the content
 // of each line and parses it to a slice of strings.
 func parseFileLinesToSlice(filePath string) (slice []string, err error) {
-	f := openFile(filePath)
+	f, err := openFile(filePath)
+	if err != nil {
+		return nil, err
+	}
 
 	defer func() {
 		if cerr := f.Close(); cerr != nil {
@@ -59,15 +66,55 @@ func parseFileLinesToSlice(filePath string) (slice []string, err error) {
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
-	if err := scanner.Err(); err != nil {
-		if err != io.EOF {
-			panic(err)
-		}
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		return nil, err
 	}
 
 	return lines, nil
 }
 
+// repoEntry is a line of the dot file: the path of a repository, optionally
+// followed by the path globs of the files counted, as in
+// `/src/monorepo include=services/billing exclude=services/billing/gen`
+type repoEntry struct {
+	path  string
+	paths pathFilter
+}
+
+var repoOptionsRegexp = regexp.MustCompile(`^(.*?)((?:\s+(?:include|exclude)=\S*)*)\s*$`)
+
+// parseRepoLine parses a line of the dot file
+func parseRepoLine(line string) repoEntry {
+	m := repoOptionsRegexp.FindStringSubmatch(line)
+	entry := repoEntry{path: m[1]}
+	for _, option := range strings.Fields(m[2]) {
+		parts := strings.SplitN(option, "=", 2)
+		var globs stringList
+		globs.Set(parts[1])
+		if parts[0] == "include" {
+			entry.paths.include = append(entry.paths.include, globs...)
+		} else {
+			entry.paths.exclude = append(entry.paths.exclude, globs...)
+		}
+	}
+	return entry
+}
+
+// readRepoList returns the repositories in the dot file, skipping the empty lines
+func readRepoList() ([]repoEntry, error) {
+	lines, err := parseFileLinesToSlice(getDotFilePath())
+	if err != nil {
+		return nil, err
+	}
+	var entries []repoEntry
+	for _, line := range lines {
+		if entry := parseRepoLine(line); entry.path != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
 // sliceContains returns true if `slice` contains `value`
 func sliceContains(slice []string, value string) bool {
 	for _, v := range slice {
@@ -90,23 +137,31 @@ func joinSlices(new []string, existing []string) []string {
 }
 
 // dumpStringsSliceToFile writes content to the file in path `filePath` (overwriting existing content)
-func dumpStringsSliceToFile(repos []string, filePath string) {
+func dumpStringsSliceToFile(repos []string, filePath string) error {
 	content := strings.Join(repos, "\n")
-	err := ioutil.WriteFile(filePath, []byte(content), 0755)
-	if err != nil {
-		panic("Error writing to file")
-	}
+	return ioutil.WriteFile(filePath, []byte(content), 0755)
 }
 
 // addNewSliceElementsToFile given a slice of strings representing paths, stores them
 // to the filesystem
-func addNewSliceElementsToFile(filePath string, newRepos []string) {
+func addNewSliceElementsToFile(filePath string, newRepos []string) error {
 	existingRepos, err := parseFileLinesToSlice(filePath)
 	if err != nil {
-		panic("Error closing file")
+		return err
 	}
-	repos := joinSlices(newRepos, existingRepos)
-	dumpStringsSliceToFile(repos, filePath)
+	// the lines with path globs are kept as they are
+	var existingPaths []string
+	for _, line := range existingRepos {
+		existingPaths = append(existingPaths, parseRepoLine(line).path)
+	}
+	repos := existingRepos
+	for _, repo := range newRepos {
+		if !sliceContains(existingPaths, repo) {
+			repos = append(repos, repo)
+			existingPaths = append(existingPaths, repo)
+		}
+	}
+	return dumpStringsSliceToFile(repos, filePath)
 }
 
 // recursiveScanFolder starts the recursive search of git repositories
@@ -116,12 +171,15 @@ func recursiveScanFolder(folder string) []string {
 }
 
 // scan scans a new folder for Git repositories
-func scan(folder string) {
+func scan(folder string) error {
 	fmt.Printf("Found folders:\n\n")
 	repositories := recursiveScanFolder(folder)
 	filePath := getDotFilePath()
-	addNewSliceElementsToFile(filePath, repositories)
+	if err := addNewSliceElementsToFile(filePath, repositories); err != nil {
+		return err
+	}
 	fmt.Printf("\n\nSuccessfully added\n\n")
+	return nil
 }
 
 // scanGitFolders returns a list of subfolders of `folder` ending with `.git`.
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// server serves the dashboard, the JSON API and the Prometheus metrics.
+// The stats are calculated again on each request, from the command flags
+// and the query parameters, except for the metrics.
+type server struct {
+	flags *statsFlags
+	eo    *exportOptions
+	// refresh is how long the metrics are served before being calculated again
+	refresh time.Duration
+
+	mu      sync.Mutex
+	metrics *metricsSnapshot
+}
+
+// metricsSnapshot is the last calculation of the metrics
+type metricsSnapshot struct {
+	rep      *report
+	tracked  int
+	duration time.Duration
+	at       time.Time
+}
+
+// newServer returns the handler of the `serve` command
+func newServer(sf *statsFlags, eo *exportOptions, refresh time.Duration) http.Handler {
+	s := &server{flags: sf, eo: eo, refresh: refresh}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleDashboard)
+	mux.HandleFunc("/graph.svg", s.handleGraph)
+	mux.HandleFunc("/api/stats", s.handleStats)
+	mux.HandleFunc("/api/repos", s.handleRepos)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	return mux
+}
+
+// options returns the stats options of the command flags, overridden by
+// the since, until, year, tz, email, author and repo query parameters
+func (s *server) options(req *http.Request) (*statsOptions, error) {
+	// relative ranges move with the days the server stays up
+	now := time.Now()
+	opts, err := s.flags.options(now)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+
+	if q.Get("since") != "" || q.Get("until") != "" || q.Get("year") != "" || q.Get("tz") != "" {
+		rf := &rangeFlags{since: q.Get("since"), until: q.Get("until"), tz: s.flags.timeRange.tz}
+		if tz := q.Get("tz"); tz != "" {
+			rf.tz = tz
+		}
+		if year := q.Get("year"); year != "" {
+			if rf.year, err = strconv.Atoi(year); err != nil {
+				return nil, fmt.Errorf("invalid year %q", year)
+			}
+		}
+		if opts.r, err = rf.timeRange(now); err != nil {
+			return nil, err
+		}
+	}
+
+	idf := &identityFlags{}
+	for _, email := range q["email"] {
+		idf.emails.Set(email)
+	}
+	for _, author := range q["author"] {
+		idf.authors.Set(author)
+	}
+	if len(idf.emails) > 0 || len(idf.authors) > 0 {
+		if opts.id, err = idf.identity(); err != nil {
+			return nil, err
+		}
+		// each identity has its own cache files, don't let the
+		// requests fill the disk with them
+		opts.readOnlyCache = true
+	}
+
+	var repos stringList
+	for _, repo := range q["repo"] {
+		repos.Set(repo)
+	}
+	if len(repos) > 0 {
+		opts.repos = repos
+	}
+
+	return opts, nil
+}
+
+// report calculates the stats of the request, writing an error
+// response and returning nil if the query is not valid or the
+// dot file can't be read
+func (s *server) report(w http.ResponseWriter, req *http.Request) *report {
+	opts, err := s.options(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	rep, err := processRepositories(opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+	printFailures(rep.failures)
+	return rep
+}
+
+// handleGraph serves the contributions graph as SVG
+func (s *server) handleGraph(w http.ResponseWriter, req *http.Request) {
+	rep := s.report(w, req)
+	if rep == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := writeSVG(&buf, rep, s.eo); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(buf.Bytes())
+}
+
+// handleStats serves the stats as JSON, in the format of the JSON export
+func (s *server) handleStats(w http.ResponseWriter, req *http.Request) {
+	rep := s.report(w, req)
+	if rep == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, rep, s.eo); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf.Bytes())
+}
+
+// handleRepos serves the list of the tracked repositories as JSON
+func (s *server) handleRepos(w http.ResponseWriter, req *http.Request) {
+	repos, err := s.trackedRepos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	type jsonTrackedRepository struct {
+		Path   string `json:"path"`
+		Exists bool   `json:"exists"`
+	}
+	out := []jsonTrackedRepository{}
+	for _, repo := range repos {
+		out = append(out, jsonTrackedRepository{Path: repo, Exists: folderExists(repo)})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// handleMetrics serves the stats of the command flags in the Prometheus
+// text exposition format. The scrapes get the last calculation, until
+// it's older than the refresh interval.
+func (s *server) handleMetrics(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.metrics == nil || time.Since(s.metrics.at) >= s.refresh {
+		opts, err := s.flags.options(time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		tracked, err := s.trackedRepos()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		start := time.Now()
+		rep, err := processRepositories(opts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		printFailures(rep.failures)
+		s.metrics = &metricsSnapshot{rep: rep, tracked: len(tracked), duration: time.Since(start), at: start}
+	}
+
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, s.metrics); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// trackedRepos returns the paths of the repositories in the dot file
+func (s *server) trackedRepos() ([]string, error) {
+	entries, err := readRepoList()
+	if err != nil {
+		return nil, err
+	}
+	var repos []string
+	for _, entry := range entries {
+		repos = append(repos, entry.path)
+	}
+	return repos, nil
+}
+
+// dashboardRepository is a tracked repository in the repository filter
+type dashboardRepository struct {
+	Path     string
+	Selected bool
+}
+
+// dashboard is the data rendered by dashboardTemplate
+type dashboard struct {
+	Since  string
+	Until  string
+	Emails string
+	// Authors are the author patterns, with an input each
+	Authors []string
+	// Default is the identity used when no emails and authors are selected
+	Default      string
+	Identities   []string
+	Tracked      []dashboardRepository
+	Query        template.URL
+	Commits      int
+	Graph        template.HTML
+	Repositories []htmlRepository
+	Failures     []jsonFailure
+}
+
+// handleDashboard serves the HTML dashboard, with the form selecting the
+// time range, the identity and the repositories
+func (s *server) handleDashboard(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(w, req)
+		return
+	}
+	opts, err := s.options(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rep, err := processRepositories(opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	printFailures(rep.failures)
+
+	var graph bytes.Buffer
+	if err := writeSVG(&graph, rep, s.eo); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out := dashboard{
+		Since:      opts.r.since.Format(dateFormat),
+		Until:      opts.r.until.Format(dateFormat),
+		Emails:     strings.Join(req.URL.Query()["email"], ", "),
+		Authors:    append(req.URL.Query()["author"], ""),
+		Default:    opts.id.String(),
+		Identities: rep.identities(),
+		Query:      template.URL(req.URL.RawQuery),
+		Graph:      template.HTML(graph.String()),
+	}
+	out.Repositories, out.Commits = repositoryRows(rep)
+	for _, f := range rep.failures {
+		out.Failures = append(out.Failures, jsonFailure{Path: f.path, Error: f.err.Error()})
+	}
+
+	tracked, err := s.trackedRepos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, path := range tracked {
+		selected := len(opts.repos) > 0 && opts.includesRepo(path)
+		out.Tracked = append(out.Tracked, dashboardRepository{Path: path, Selected: selected})
+	}
+
+	var buf bytes.Buffer
+	if err := dashboardTemplate.Execute(&buf, out); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gogitlocalstats</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292e; margin: 2em auto; max-width: 960px; padding: 0 1em; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.1em; margin-top: 2em; border-bottom: 1px solid #eaecef; padding-bottom: .3em; }
+form { display: flex; flex-wrap: wrap; gap: 1em; align-items: flex-end; margin-bottom: 2em; }
+label { display: flex; flex-direction: column; font-size: .85em; color: #586069; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .3em .6em; border-bottom: 1px solid #eaecef; }
+td.num, th.num { text-align: right; }
+.meta { color: #586069; }
+.failed { color: #cb2431; }
+</style>
+</head>
+<body>
+<h1>Git contributions</h1>
+<form method="get" action="/">
+<label>Since <input type="date" name="since" value="{{.Since}}"></label>
+<label>Until <input type="date" name="until" value="{{.Until}}"></label>
+<label>Emails <input type="text" name="email" value="{{.Emails}}" list="identities" size="30" placeholder="{{.Default}}"></label>
+<label>Authors {{range .Authors}}<input type="text" name="author" value="{{.}}" size="20">{{end}}</label>
+<label>Repositories <select name="repo" multiple size="4">
+{{range .Tracked}}<option value="{{.Path}}"{{if .Selected}} selected{{end}}>{{.Path}}</option>
+{{end}}</select></label>
+<datalist id="identities">{{range .Identities}}<option value="{{.}}">{{end}}</datalist>
+<button type="submit">Update</button>
+</form>
+
+<p class="meta">From {{.Since}} to {{.Until}}, {{.Commits}} commits. <a href="/api/stats?{{.Query}}">JSON</a> · <a href="/graph.svg?{{.Query}}">SVG</a></p>
+
+{{.Graph}}
+
+<h2>Repositories</h2>
+<table>
+<tr><th>Repository</th><th class="num">Commits</th><th class="num">Active days</th></tr>
+{{range .Repositories}}<tr><td>{{.Path}}</td><td class="num">{{.Commits}}</td><td class="num">{{.ActiveDays}}</td></tr>
+{{end}}</table>
+{{if .Failures}}<p class="failed">Skipped because of errors:</p>
+<ul class="failed">
+{{range .Failures}}<li>{{.Path}}: {{.Error}}</li>
+{{end}}</ul>
+{{end}}
+</body>
+</html>
+`))
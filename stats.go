@@ -2,23 +2,67 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
 )
 
 const outOfRange = 99999
-const daysInLastSixMonths = 183
-const weeksInLastSixMonths = 26
+
+// empty marks the cells of a column outside of the time range
+const empty = -1
 
 type column []int
 
-// stats calculates and prints the stats.
-func stats(email string) {
-	commits := processRepositories(email)
-	printCommitsStats(commits)
+// repoError is the error that made the processing of a repository fail
+type repoError struct {
+	path string
+	err  error
+}
+
+// repoStats holds the commits authored by the identity in a repository
+type repoStats struct {
+	path     string
+	identity *identity
+	commits  []commitRecord
+}
+
+// report holds the stats of all the repositories, in the dot file order
+type report struct {
+	r        timeRange
+	repos    []repoStats
+	failures []repoError
+	// lines tells the commits hold the lines added and removed
+	lines bool
+}
+
+// stats calculates the stats and prints them with `print`, followed by
+// the repositories that couldn't be processed, which are returned.
+// Returns an error if the dot file can't be read.
+func stats(opts *statsOptions, print func(rep *report)) ([]repoError, error) {
+	rep, err := processRepositories(opts)
+	if err != nil {
+		return nil, err
+	}
+	print(rep)
+	printFailures(rep.failures)
+	return rep.failures, nil
+}
+
+// printGraph prints the contributions graph of the report, followed
+// by its summary
+func printGraph(rep *report) {
+	printCommitsStats(rep.heatmap(), rep.r)
+	fmt.Printf("\n")
+	printSummary(rep)
 }
 
 // getBeginningOfDay given a time.Time calculates the start time of that day
@@ -28,118 +72,436 @@ func getBeginningOfDay(t time.Time) time.Time {
 	return startOfDay
 }
 
-// countDaysSinceDate counts how many days passed since the passed `date`
-func countDaysSinceDate(date time.Time) int {
-	days := 0
-	now := getBeginningOfDay(time.Now())
-	for date.Before(now) {
-		date = date.Add(time.Hour * 24)
-		days++
-		if days > daysInLastSixMonths {
-			return outOfRange
-		}
+// countDaysSinceDate counts how many days passed between the passed `date`
+// and the last day of `r`. Returns outOfRange if `date` is not in `r`.
+func countDaysSinceDate(date time.Time, r timeRange) int {
+	days := daysBetween(r.inZone(date), r.until)
+	if days < 0 || days >= r.days() {
+		return outOfRange
 	}
 	return days
 }
 
-// fillCommits given a repository found in `path`, gets the commits and
-// puts them in the `commits` map, returning it when completed
-func fillCommits(email string, path string, commits *map[int]int) *map[int]int {
+// daysBetween counts the calendar days from the date of `from` to the
+// date of `to`, regardless of the hours and of the DST changes in between
+func daysBetween(from time.Time, to time.Time) int {
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := to.Date()
+	start := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// commitRecord is a commit authored, or committed, by the identity
+type commitRecord struct {
+	Hash string `json:"hash"`
+	// Email is the email of the author, or of the committer when just
+	// the committer matched, as mapped by the .mailmap file
+	Email string `json:"email"`
+	// When is the author date, or the committer date, in its own timezone
+	When time.Time `json:"when"`
+	// the lines added and removed, only counted with the lines option
+	Additions int `json:"additions,omitempty"`
+	Deletions int `json:"deletions,omitempty"`
+	// Languages are the lines changed in the files of each language
+	// touched, only counted with the lines option
+	Languages map[string]int `json:"languages,omitempty"`
+}
+
+// fillCommits given a repository found in `path`, gets the commits
+// authored by `id` back to the start of the time range, touching the
+// files selected by `paths`. Unless disabled, the commits walked are
+// cached and the next calls only walk the new ones.
+// Returns an error if the repository can't be read.
+func fillCommits(id *identity, path string, paths pathFilter, opts *statsOptions) ([]commitRecord, error) {
 	// instantiate a git repo object from path
 	repo, err := git.PlainOpen(path)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("opening the repository: %v", err)
 	}
-	// get the HEAD reference
-	ref, err := repo.Head()
+	// collapse the author aliases listed in the .mailmap file
+	mm, err := readMailmap(path)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("reading .mailmap: %v", err)
+	}
+	return walkRepository(repo, path, id.withMailmap(mm), mm, paths, opts)
+}
+
+// walkRepository gets the commits of fillCommits from `repo`, the
+// repository found in `path`, whose .mailmap is `mm`
+func walkRepository(repo *git.Repository, path string, id *identity, mm *mailmap, paths pathFilter, opts *statsOptions) ([]commitRecord, error) {
+	// get HEAD, and the other refs when asked to
+	starts, err := startingCommits(repo, opts)
+	if err == plumbing.ErrReferenceNotFound {
+		// no commits yet
+		return nil, nil
 	}
-	// get the commits history starting from HEAD
-	iterator, err := repo.Log(&git.LogOptions{From: ref.Hash()})
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("reading the refs: %v", err)
+	}
+	tips := make([]string, len(starts))
+	for i, c := range starts {
+		tips[i] = c.Hash.String()
+	}
+	// a day of margin for the commits made in other timezones
+	stopAt := opts.r.since.AddDate(0, 0, -1)
+
+	var cacheFile string
+	var cached *cacheEntry
+	if opts.cache {
+		cacheFile = getCacheFilePath(path, cacheKey(id, mm, paths, opts))
+		cached = loadCache(cacheFile)
+		if cached != nil && cached.Since.After(stopAt) {
+			// the cached walk stopped too early
+			cached = nil
+		}
+		if cached != nil && sameStrings(cached.Tips, tips) {
+			return cached.Commits, nil
+		}
+	}
+
+	var records []commitRecord
+	var exclude []*object.Commit
+	if cached != nil {
+		for _, tip := range cached.Tips {
+			c, err := repo.CommitObject(plumbing.NewHash(tip))
+			if err != nil {
+				// rewritten history, walk it all again
+				cached, exclude = nil, nil
+				break
+			}
+			exclude = append(exclude, c)
+		}
+	}
+	if cached != nil {
+		records = append(records, cached.Commits...)
+		stopAt = cached.Since
 	}
-	// iterate the commits
-	offset := calcOffset()
-	err = iterator.ForEach(func(c *object.Commit) error {
-		daysAgo := countDaysSinceDate(c.Author.When) + offset
 
-		if c.Author.Email != email {
+	collect := func(c *object.Commit) error {
+		// the next commits are all older than the time range
+		if c.Committer.When.Before(stopAt) {
+			return storer.ErrStop
+		}
+		if opts.filter.skips(c) {
 			return nil
 		}
 
-		if daysAgo != outOfRange {
-			(*commits)[daysAgo]++
+		email, ok := "", false
+		if opts.match != "committer" {
+			email, ok = matchSignature(id, mm, c.Author)
+		}
+		if !ok && opts.match != "author" {
+			email, ok = matchSignature(id, mm, c.Committer)
+		}
+		if !ok {
+			return nil
+		}
+
+		when := c.Author.When
+		if opts.date == "committer" {
+			when = c.Committer.When
 		}
 
+		record := commitRecord{Hash: c.Hash.String(), Email: strings.ToLower(email), When: when}
+		if opts.lines || len(opts.filter.generated) > 0 || !paths.isEmpty() {
+			// the diff against the first parent
+			fileStats, err := c.Stats()
+			if err != nil {
+				return fmt.Errorf("diffing %s: %v", c.Hash, err)
+			}
+			if opts.filter.onlyGenerated(fileStats) || !paths.isEmpty() && !paths.counts(fileStats) {
+				return nil
+			}
+			// as git log --numstat, the merges change no lines
+			if opts.lines && c.NumParents() <= 1 {
+				record.Languages = make(map[string]int)
+				for _, fs := range fileStats {
+					// only the lines of the files counted
+					if !paths.includes(fs.Name) {
+						continue
+					}
+					record.Additions += fs.Addition
+					record.Deletions += fs.Deletion
+					record.Languages[languageOf(fs.Name)] += fs.Addition + fs.Deletion
+				}
+			}
+		}
+		records = append(records, record)
 		return nil
-	})
+	}
+	reached, err := walkCommits(starts, exclude, collect)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("walking the history: %v", err)
+	}
+
+	// the cached commits count only if they're still part of the history
+	for _, c := range exclude {
+		if !reached[c.Hash] && !sliceContains(tips, c.Hash.String()) {
+			records, stopAt = nil, opts.r.since.AddDate(0, 0, -1)
+			if _, err := walkCommits(starts, nil, collect); err != nil {
+				return nil, fmt.Errorf("walking the history: %v", err)
+			}
+			break
+		}
+	}
+
+	// drop the commits older than the time range, so that the cache
+	// doesn't grow with the history. If the range moves back, the
+	// cached walk stopped too early and the history is walked again.
+	cutoff := opts.r.since.AddDate(0, 0, -1)
+	var kept []commitRecord
+	for _, record := range records {
+		if !record.When.Before(cutoff) {
+			kept = append(kept, record)
+		}
+	}
+	records = kept
+	if cutoff.After(stopAt) {
+		stopAt = cutoff
+	}
+
+	if opts.cache && !opts.readOnlyCache {
+		entry := &cacheEntry{Path: path, Tips: tips, Since: stopAt, Commits: records}
+		if err := saveCache(cacheFile, entry); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: can't write the cache of %s: %v\n", path, err)
+		}
 	}
 
-	return commits
+	return records, nil
+}
+
+// matchSignature returns true, with the email mapped by the .mailmap
+// file, if the author or the committer in `sig` is the identity
+func matchSignature(id *identity, mm *mailmap, sig object.Signature) (string, bool) {
+	name, email := mm.resolve(sig.Name, sig.Email)
+	if !id.matches(name, email) && !id.matches(sig.Name, sig.Email) {
+		return "", false
+	}
+	return email, true
+}
+
+// cacheKey returns what, besides the repository, determines the
+// commits found by fillCommits
+func cacheKey(id *identity, mm *mailmap, paths pathFilter, opts *statsOptions) string {
+	return fmt.Sprintf("%s|%v|%v|%v|%v|%v|%s|%s|%s|%s", id, mm.entries, opts.allRefs, opts.remotes, opts.tags, opts.lines, opts.date, opts.match, opts.filter, paths)
+}
+
+// sameStrings returns true if `a` and `b` hold the same strings, in any order
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, v := range a {
+		if !sliceContains(b, v) {
+			return false
+		}
+	}
+	return true
 }
 
-// processRepositories given an user email, returns the
-// commits made in the last 6 months
-func processRepositories(email string) *map[int]int {
-	filePath := getDotFilePath()
-	repos, err := parseFileLinesToSlice(filePath)
+// processRepositories given the stats options, returns the commits made
+// by the identity in each repository, and the repositories that failed,
+// or an error if the dot file can't be read.
+// Repositories are processed in parallel by `opts.jobs` workers.
+func processRepositories(opts *statsOptions) (*report, error) {
+	entries, err := readRepoList()
 	if err != nil {
-		panic("Error closing file")
+		return nil, err
+	}
+	var repos []string
+	var paths []pathFilter
+	for _, entry := range entries {
+		if opts.includesRepo(entry.path) {
+			repos = append(repos, entry.path)
+			paths = append(paths, entry.paths.merge(opts.filter.paths))
+		}
+	}
+	var unresolved []string
+	ids := make([]*identity, len(repos))
+	for i, path := range repos {
+		ids[i] = opts.id.forRepository(path)
+		if ids[i].isEmpty() {
+			unresolved = append(unresolved, path)
+		}
+	}
+	printUnresolvedWarning(unresolved, len(repos))
+
+	// each repository gets its own slice, collected in the repositories
+	// order once all the workers are done
+	results := make([][]commitRecord, len(repos))
+	errs := make([]error, len(repos))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = fillCommits(ids[i], repos[i], paths[i], opts)
+			}
+		}()
 	}
-	daysInMap := daysInLastSixMonths
+	for i := range repos {
+		if !ids[i].isEmpty() {
+			indexes <- i
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	rep := &report{r: opts.r, lines: opts.lines}
+	for i, records := range results {
+		if ids[i].isEmpty() {
+			continue
+		}
+		if errs[i] != nil {
+			rep.failures = append(rep.failures, repoError{path: repos[i], err: errs[i]})
+			continue
+		}
+		rep.repos = append(rep.repos, repoStats{path: repos[i], identity: ids[i], commits: records})
+	}
+
+	return rep, nil
+}
+
+// dailyCommits returns the commits made in all the repositories,
+// keyed by the days passed since the last day of the time range
+func (rep *report) dailyCommits() *map[int]int {
+	daysInMap := rep.r.days()
 
 	commits := make(map[int]int, daysInMap)
-	for i := daysInMap; i > 0; i-- {
+	for i := daysInMap - 1; i >= 0; i-- {
 		commits[i] = 0
 	}
-
-	for _, path := range repos {
-		fillCommits(email, path, &commits)
+	for _, repo := range rep.repos {
+		countDailyCommits(repo.commits, rep.r, &commits)
 	}
 
 	return &commits
 }
 
-// calcOffset determines and returns the amount of days missing to fill
-// the last row of the stats graph
-func calcOffset() int {
-	var offset int
-	weekday := time.Now().Weekday()
+// dailyLines returns the lines added and removed in all the repositories,
+// keyed by the days passed since the last day of the time range
+func (rep *report) dailyLines() *map[int]int {
+	daysInMap := rep.r.days()
+
+	lines := make(map[int]int, daysInMap)
+	for i := daysInMap - 1; i >= 0; i-- {
+		lines[i] = 0
+	}
+	for _, repo := range rep.repos {
+		for _, record := range repo.commits {
+			if daysAgo := countDaysSinceDate(record.When, rep.r); daysAgo != outOfRange {
+				lines[daysAgo] += record.Additions + record.Deletions
+			}
+		}
+	}
+
+	return &lines
+}
+
+// totals returns the commits, and the lines added and removed,
+// in the time range
+func (rep *report) totals() (commits int, additions int, deletions int) {
+	for _, repo := range rep.repos {
+		for _, record := range repo.commits {
+			if countDaysSinceDate(record.When, rep.r) != outOfRange {
+				commits++
+				additions += record.Additions
+				deletions += record.Deletions
+			}
+		}
+	}
+	return commits, additions, deletions
+}
+
+// heatmap holds the values of the graph cells, keyed by days ago
+type heatmap struct {
+	values *map[int]int
+	// levels are the lowest values of the color levels 1, 2 and 3
+	levels [3]int
+	// label describes a value, as in "3 commits"
+	label func(val int) string
+}
+
+// the levels of the graph colored by commits, and by lines changed
+var (
+	commitLevels = [3]int{1, 5, 10}
+	lineLevels   = [3]int{1, 100, 500}
+)
+
+// heatmap returns the values of the graph: the commits of each day,
+// or the lines changed when the lines are counted
+func (rep *report) heatmap() *heatmap {
+	if rep.lines {
+		return &heatmap{values: rep.dailyLines(), levels: lineLevels, label: linesLabel}
+	}
+	return &heatmap{values: rep.dailyCommits(), levels: commitLevels, label: commitsLabel}
+}
 
-	switch weekday {
-	case time.Sunday:
-		offset = 7
-	case time.Monday:
-		offset = 6
-	case time.Tuesday:
-		offset = 5
-	case time.Wednesday:
-		offset = 4
-	case time.Thursday:
-		offset = 3
-	case time.Friday:
-		offset = 2
-	case time.Saturday:
-		offset = 1
+// countDailyCommits adds the `records` in the `r` time range to
+// the `commits` map, keyed by days ago
+func countDailyCommits(records []commitRecord, r timeRange, commits *map[int]int) {
+	for _, record := range records {
+		if daysAgo := countDaysSinceDate(record.When, r); daysAgo != outOfRange {
+			(*commits)[daysAgo]++
+		}
 	}
+}
 
-	return offset
+// identities returns the emails and patterns matched in the repositories
+func (rep *report) identities() []string {
+	var ids []string
+	for _, repo := range rep.repos {
+		ids = joinSlices(repo.identity.list(), ids)
+	}
+	return ids
+}
+
+// printFailures prints the repositories that couldn't be processed, and why
+func printFailures(failures []repoError) {
+	if len(failures) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n%d repositories skipped because of errors:\n", len(failures))
+	for _, f := range failures {
+		fmt.Fprintf(os.Stderr, "  %s: %v\n", f.path, f.err)
+	}
+}
+
+// printUnresolvedWarning warns that no identity could be resolved for the
+// `unresolved` repositories, which were skipped
+func printUnresolvedWarning(unresolved []string, total int) {
+	if len(unresolved) == 0 {
+		return
+	}
+	if len(unresolved) == total {
+		fmt.Fprintf(os.Stderr, "warning: no identity to scan, pass -email or run `git config --global user.email <email>`\n")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "warning: no identity to scan, skipped %d repositories without user.email:\n", len(unresolved))
+	for _, path := range unresolved {
+		fmt.Fprintf(os.Stderr, "  %s\n", path)
+	}
+}
+
+// weekOffset determines and returns the amount of days missing to fill
+// the last column of the stats graph, after the last day of `r`
+func weekOffset(r timeRange) int {
+	return int(time.Saturday - r.until.Weekday())
 }
 
 // printCell given a cell value prints it with a different format
-// based on the value amount, and on the `today` flag.
-func printCell(val int, today bool) {
+// based on the value level, and on the `today` flag.
+func printCell(val int, today bool, levels [3]int) {
 	escape := "\033[0;37;30m"
-	switch {
-	case val > 0 && val < 5:
+	switch cellLevel(val, levels) {
+	case 1:
 		escape = "\033[1;30;47m"
-	case val >= 5 && val < 10:
+	case 2:
 		escape = "\033[1;30;43m"
-	case val >= 10:
+	case 3:
 		escape = "\033[1;30;42m"
 	}
 
@@ -147,27 +509,30 @@ func printCell(val int, today bool) {
 		escape = "\033[1;37;45m"
 	}
 
+	if val == empty {
+		fmt.Printf("    ")
+		return
+	}
+
 	if val == 0 {
 		fmt.Printf(escape + "  - " + "\033[0m")
 		return
 	}
 
-	str := "  %d "
-	switch {
-	case val >= 10:
-		str = " %d "
-	case val >= 100:
-		str = "%d "
+	// thousands of lines don't fit the cell
+	str := strconv.Itoa(val)
+	if val >= 1000 {
+		str = strconv.Itoa(val/1000) + "k"
 	}
 
-	fmt.Printf(escape+str+"\033[0m", val)
+	fmt.Printf(escape+"%3s "+"\033[0m", str)
 }
 
 // printCommitsStats prints the commits stats
-func printCommitsStats(commits *map[int]int) {
-	keys := sortMapIntoSlice(commits)
-	cols := buildCols(keys, commits)
-	printCells(cols)
+func printCommitsStats(hm *heatmap, r timeRange) {
+	keys := sortMapIntoSlice(hm.values)
+	cols := buildCols(keys, hm.values, r)
+	printCells(cols, r, hm.levels)
 }
 
 // sortMapIntoSlice returns a slice of indexes of a map, ordered
@@ -183,50 +548,45 @@ func sortMapIntoSlice(m *map[int]int) *[]int {
 	return &keys
 }
 
-// buildCols generates a map with rows and columns ready to be printed to screen
-func buildCols(keys *[]int, commits *map[int]int) *map[int]column {
+// buildCols generates a map with rows and columns ready to be printed to screen.
+// Columns are indexed by the week, 0 being the last one, and each holds
+// the 7 days of the week starting from Sunday.
+func buildCols(keys *[]int, commits *map[int]int, r timeRange) *map[int]column {
 	cols := make(map[int]column)
-	col := column{}
+	offset := weekOffset(r)
 
-	for _, k := range *keys {
-		week := k / 7      //26,25...1
-		dayinweek := k % 7 // 0,1,2,3,4,5,6
+	for week := 0; week < r.weeks(); week++ {
+		cols[week] = column{empty, empty, empty, empty, empty, empty, empty}
+	}
 
-		if dayinweek == 0 { //reset
-			col = column{}
+	for _, k := range *keys {
+		if k >= r.days() {
+			continue
 		}
+		week := (k + offset) / 7
+		dayinweek := 6 - (k+offset)%7 // 0 is Sunday
 
-		col = append(col, (*commits)[k])
-
-		if dayinweek == 6 {
-			cols[week] = col
-		}
+		cols[week][dayinweek] = (*commits)[k]
 	}
 
 	return &cols
 }
 
 // printCells prints the cells of the graph
-func printCells(cols *map[int]column) {
-	printMonths()
-	for j := 6; j >= 0; j-- {
-		for i := weeksInLastSixMonths + 1; i >= 0; i-- {
-			if i == weeksInLastSixMonths+1 {
-				printDayCol(j)
-			}
-			if col, ok := (*cols)[i]; ok {
-				//special case today
-				if i == 0 && j == calcOffset()-1 {
-					printCell(col[j], true)
-					continue
-				} else {
-					if len(col) > j {
-						printCell(col[j], false)
-						continue
-					}
-				}
-			}
-			printCell(0, false)
+func printCells(cols *map[int]column, r timeRange, levels [3]int) {
+	todayWeek, todayDay := -1, -1
+	if today := getBeginningOfDay(time.Now().In(r.until.Location())); r.contains(today) {
+		daysAgo := countDaysSinceDate(today, r)
+		todayWeek = (daysAgo + weekOffset(r)) / 7
+		todayDay = int(today.Weekday())
+	}
+
+	printMonths(r)
+	for j := 0; j <= 6; j++ {
+		printDayCol(j)
+		for i := r.weeks() - 1; i >= 0; i-- {
+			col := (*cols)[i]
+			printCell(col[j], i == todayWeek && j == todayDay, levels)
 		}
 		fmt.Printf("\n")
 	}
@@ -234,24 +594,41 @@ func printCells(cols *map[int]column) {
 
 // printMonths prints the month names in the first line, determining when the month
 // changed between switching weeks
-func printMonths() {
-	week := getBeginningOfDay(time.Now()).Add(-(daysInLastSixMonths * time.Hour * 24))
-	month := week.Month()
-	fmt.Printf("         ")
-	for {
-		if week.Month() != month {
-			fmt.Printf("%s ", week.Month().String()[:3])
-			month = week.Month()
+func printMonths(r timeRange) {
+	fmt.Printf("     ")
+	for _, label := range monthLabels(r) {
+		if label != "" {
+			fmt.Printf("%s ", label)
 		} else {
 			fmt.Printf("    ")
 		}
+	}
+	fmt.Printf("\n")
+}
 
-		week = week.Add(7 * time.Hour * 24)
-		if week.After(time.Now()) {
-			break
+// monthLabels returns the label of each column of the graph, oldest first:
+// the month name when the month changed since the previous column, or an
+// empty string. The first column is labeled with the month of its first
+// day in range.
+func monthLabels(r timeRange) []string {
+	var labels []string
+	week := r.since.AddDate(0, 0, -int(r.since.Weekday()))
+	month := time.Month(0)
+	for !week.After(r.until) {
+		day := week
+		if day.Before(r.since) {
+			day = r.since
+		}
+		label := ""
+		if day.Month() != month {
+			label = day.Month().String()[:3]
+			month = day.Month()
 		}
+		labels = append(labels, label)
+
+		week = week.AddDate(0, 0, 7)
 	}
-	fmt.Printf("\n")
+	return labels
 }
 
 // printDayCol given the day number (0 is Sunday) prints the day name,
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// testRepository is an in-memory repository the tests commit to
+type testRepository struct {
+	t    *testing.T
+	repo *git.Repository
+	wt   *git.Worktree
+	fs   billy.Filesystem
+}
+
+// newTestRepository returns an empty in-memory repository
+func newTestRepository(t *testing.T) *testRepository {
+	t.Helper()
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testRepository{t: t, repo: repo, wt: wt, fs: fs}
+}
+
+// commit commits a change to the `name` file, authored by me@example.com
+// at `authored` and committed at `committed`
+func (tr *testRepository) commit(name string, authored time.Time, committed time.Time) string {
+	tr.t.Helper()
+	f, err := tr.fs.Create(name)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	f.Write([]byte(name + "\n"))
+	f.Close()
+	if _, err := tr.wt.Add(name); err != nil {
+		tr.t.Fatal(err)
+	}
+	hash, err := tr.wt.Commit(name, &git.CommitOptions{
+		Author:    &object.Signature{Name: "Me", Email: "me@example.com", When: authored},
+		Committer: &object.Signature{Name: "Me", Email: "me@example.com", When: committed},
+	})
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return hash.String()
+}
+
+// reset moves the current branch to `hash`, as `git reset --hard`
+func (tr *testRepository) reset(hash string) {
+	tr.t.Helper()
+	err := tr.wt.Reset(&git.ResetOptions{Commit: plumbing.NewHash(hash), Mode: git.HardReset})
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+}
+
+// walk returns the hashes of the commits found by walkRepository
+func (tr *testRepository) walk(opts *statsOptions) []string {
+	tr.t.Helper()
+	records, err := walkRepository(tr.repo, "/src/"+tr.t.Name(), opts.id, &mailmap{}, pathFilter{}, opts)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	var hashes []string
+	for _, record := range records {
+		hashes = append(hashes, record.Hash)
+	}
+	return hashes
+}
+
+func TestWalkRepositoryCache(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 12, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		// change changes the history after the first walk, given
+		// the commits of the history, returning the expected commits
+		change func(tr *testRepository, commits map[string]string) []string
+	}{
+		{"new commit on top", func(tr *testRepository, commits map[string]string) []string {
+			top := tr.commit("top", day(2, 4), day(2, 4))
+			return []string{commits["first"], commits["second"], top}
+		}},
+		{"reset to an ancestor", func(tr *testRepository, commits map[string]string) []string {
+			tr.reset(commits["first"])
+			return []string{commits["first"]}
+		}},
+		{"force-push", func(tr *testRepository, commits map[string]string) []string {
+			tr.reset(commits["first"])
+			amended := tr.commit("amended", day(2, 3), day(2, 5))
+			return []string{commits["first"], amended}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			id, err := newIdentity([]string{"me@example.com"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := &statsOptions{
+				id:     id,
+				r:      timeRange{since: day(1, 1), until: day(3, 31)},
+				cache:  true,
+				date:   "author",
+				match:  "author",
+				filter: &commitFilter{},
+			}
+
+			tr := newTestRepository(t)
+			commits := map[string]string{
+				"old": tr.commit("old", day(1, 1).AddDate(0, -1, 0), day(1, 1).AddDate(0, -1, 0)),
+				// rebased in the time range, authored before it
+				"rebased": tr.commit("rebased", day(1, 1).AddDate(0, -2, 0), day(2, 1)),
+				"first":   tr.commit("first", day(2, 2), day(2, 2)),
+				"second":  tr.commit("second", day(2, 3), day(2, 3)),
+			}
+
+			want := []string{commits["first"], commits["second"]}
+			if got := tr.walk(opts); !sameStrings(got, want) {
+				t.Fatalf("first walk: got %v, want %v", got, want)
+			}
+			cached := loadCache(getCacheFilePath("/src/"+t.Name(), cacheKey(id, &mailmap{}, pathFilter{}, opts)))
+			if cached == nil {
+				t.Fatal("first walk: no cache written")
+			}
+			if cutoff := opts.r.since.AddDate(0, 0, -1); !cached.Since.Equal(cutoff) {
+				t.Errorf("first walk: cached since %v, want %v", cached.Since, cutoff)
+			}
+			if len(cached.Commits) != len(want) {
+				t.Errorf("first walk: cached %d commits, want %d", len(cached.Commits), len(want))
+			}
+
+			want = tt.change(tr, commits)
+			if got := tr.walk(opts); !sameStrings(got, want) {
+				t.Errorf("cached walk: got %v, want %v", got, want)
+			}
+			opts.cache = false
+			if got := tr.walk(opts); !sameStrings(got, want) {
+				t.Errorf("uncached walk: got %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// summary holds the figures printed below the graph
+type summary struct {
+	commits    int
+	activeDays int
+	// busiest is the day with the most commits, the latest one on ties
+	busiest        time.Time
+	busiestCommits int
+	// the days in a row with commits, the current streak ending on the
+	// last day of the time range, or on the day before if that has none yet
+	currentStreak int
+	longestStreak int
+	activeRepos   int
+	additions     int
+	deletions     int
+}
+
+// summary returns the summary of the report
+func (rep *report) summary() summary {
+	s := summary{}
+	commits := rep.dailyCommits()
+
+	streak := 0
+	for daysAgo := rep.r.days() - 1; daysAgo >= 0; daysAgo-- {
+		val := (*commits)[daysAgo]
+		if val == 0 {
+			streak = 0
+			continue
+		}
+		s.commits += val
+		s.activeDays++
+		if val >= s.busiestCommits {
+			s.busiest = rep.r.until.AddDate(0, 0, -daysAgo)
+			s.busiestCommits = val
+		}
+		streak++
+		if streak > s.longestStreak {
+			s.longestStreak = streak
+		}
+	}
+
+	daysAgo := 0
+	if (*commits)[0] == 0 {
+		daysAgo = 1
+	}
+	for ; daysAgo < rep.r.days() && (*commits)[daysAgo] > 0; daysAgo++ {
+		s.currentStreak++
+	}
+
+	for _, repo := range rep.repos {
+		for _, record := range repo.commits {
+			if countDaysSinceDate(record.When, rep.r) != outOfRange {
+				s.activeRepos++
+				break
+			}
+		}
+	}
+	_, s.additions, s.deletions = rep.totals()
+
+	return s
+}
+
+// printSummary prints the summary of the report
+func printSummary(rep *report) {
+	s := rep.summary()
+
+	fmt.Printf("Commits:         %d", s.commits)
+	if rep.lines {
+		fmt.Printf(", %d lines added, %d removed", s.additions, s.deletions)
+	}
+	fmt.Printf("\n")
+	if s.commits == 0 {
+		return
+	}
+	fmt.Printf("Busiest day:     %s, %s\n", s.busiest.Format(dateFormat), commitsLabel(s.busiestCommits))
+	fmt.Printf("Current streak:  %s\n", daysLabel(s.currentStreak))
+	fmt.Printf("Longest streak:  %s\n", daysLabel(s.longestStreak))
+	fmt.Printf("Per active day:  %.1f commits\n", float64(s.commits)/float64(s.activeDays))
+	fmt.Printf("Active repos:    %d of %d\n", s.activeRepos, len(rep.repos))
+}
+
+// daysLabel returns "1 day" or "2 days"
+func daysLabel(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// SVG graph margins, in pixels, leaving room for the labels
+const (
+	svgLeftMargin = 32
+	svgTopMargin  = 20
+)
+
+// cellLevel returns the color level of a cell holding `val`, from 0 to 3,
+// the `levels` being the lowest values of the levels 1, 2 and 3
+func cellLevel(val int, levels [3]int) int {
+	switch {
+	case val >= levels[2]:
+		return 3
+	case val >= levels[1]:
+		return 2
+	case val >= levels[0]:
+		return 1
+	}
+	return 0
+}
+
+// writeSVG writes the contributions graph as an SVG image: a column for
+// each week, a row for each weekday, the month names on top and the
+// weekday names on the left. Each cell shows its date and commits, or
+// lines changed, on hover.
+func writeSVG(w io.Writer, rep *report, eo *exportOptions) error {
+	hm := rep.heatmap()
+	keys := sortMapIntoSlice(hm.values)
+	cols := buildCols(keys, hm.values, rep.r)
+	weeks := rep.r.weeks()
+	step := eo.cellSize + eo.cellGap
+	width := svgLeftMargin + weeks*step
+	height := svgTopMargin + 7*step
+	firstSunday := rep.r.since.AddDate(0, 0, -int(rep.r.since.Weekday()))
+
+	ew := &errWriter{w: w}
+	ew.printf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"9\" fill=\"#767676\">\n", width, height, width, height)
+
+	for x, label := range monthLabels(rep.r) {
+		if label != "" {
+			ew.printf("<text x=\"%d\" y=\"%d\">%s</text>\n", svgLeftMargin+x*step, svgTopMargin-6, label)
+		}
+	}
+	// like printDayCol, just Mon, Wed and Fri
+	for _, day := range []time.Weekday{time.Monday, time.Wednesday, time.Friday} {
+		ew.printf("<text x=\"0\" y=\"%d\">%s</text>\n", svgTopMargin+int(day)*step+eo.cellSize-2, day.String()[:3])
+	}
+
+	for week := weeks - 1; week >= 0; week-- {
+		x := weeks - 1 - week
+		col := (*cols)[week]
+		for day, val := range col {
+			if val == empty {
+				continue
+			}
+			date := firstSunday.AddDate(0, 0, 7*x+day)
+			ew.printf("<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"2\" fill=\"%s\"><title>%s on %s</title></rect>\n",
+				svgLeftMargin+x*step, svgTopMargin+day*step, eo.cellSize, eo.cellSize,
+				eo.palette[cellLevel(val, hm.levels)], hm.label(val), date.Format(dateFormat))
+		}
+	}
+
+	ew.printf("</svg>\n")
+	return ew.err
+}
+
+// commitsLabel returns "1 commit", "2 commits" or "No commits"
+func commitsLabel(val int) string {
+	switch val {
+	case 0:
+		return "No commits"
+	case 1:
+		return "1 commit"
+	}
+	return fmt.Sprintf("%d commits", val)
+}
+
+// linesLabel returns "1 line changed", "2 lines changed" or "No lines changed"
+func linesLabel(val int) string {
+	switch val {
+	case 0:
+		return "No lines changed"
+	case 1:
+		return "1 line changed"
+	}
+	return fmt.Sprintf("%d lines changed", val)
+}
+
+// errWriter writes to `w` until the first error, which is kept in `err`
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
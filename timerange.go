@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDays is the amount of days shown when no range is passed
+const defaultDays = 183
+
+// timeRange is the window of days the stats are calculated on.
+// `since` and `until` are the beginning of the first and last day,
+// in the timezone the commits are bucketed in.
+type timeRange struct {
+	since time.Time
+	until time.Time
+	// authorLocal buckets the commits by their date in the author timezone
+	authorLocal bool
+}
+
+// days returns how many days are in the range, both ends included
+func (r timeRange) days() int {
+	return daysBetween(r.since, r.until) + 1
+}
+
+// weeks returns how many columns, each starting on Sunday,
+// are needed to show the range
+func (r timeRange) weeks() int {
+	return (r.days()-1+weekOffset(r))/7 + 1
+}
+
+// contains returns true if the day starting at `day` is in the range
+func (r timeRange) contains(day time.Time) bool {
+	return !day.Before(r.since) && !day.After(r.until)
+}
+
+// inZone returns `date` in the timezone its day is counted in
+func (r timeRange) inZone(date time.Time) time.Time {
+	if r.authorLocal {
+		return date
+	}
+	return date.In(r.until.Location())
+}
+
+// rangeFlags holds the flags used to select the time range
+type rangeFlags struct {
+	since string
+	until string
+	year  int
+	tz    string
+}
+
+// addRangeFlags defines the time range flags in `fs`
+func addRangeFlags(fs *flag.FlagSet) *rangeFlags {
+	f := &rangeFlags{}
+	fs.StringVar(&f.since, "since", "", "start from `date` (YYYY-MM-DD), or cover a duration up to today included (90d, 12w, 6m, 1y)")
+	fs.StringVar(&f.until, "until", "", "end at `date` (YYYY-MM-DD), or a duration ago (default today)")
+	fs.IntVar(&f.year, "year", 0, "show the whole `year`, from January 1st to December 31st")
+	fs.StringVar(&f.tz, "tz", "local", "count the days in the `zone`: local, UTC, author-local, or a name like Europe/Rome")
+	return f
+}
+
+// timeRange returns the range selected by the flags, relative to `now`.
+// Without flags the range is the last `defaultDays` days.
+func (f *rangeFlags) timeRange(now time.Time) (timeRange, error) {
+	authorLocal := false
+	switch strings.ToLower(f.tz) {
+	case "", "local":
+	case "utc":
+		now = now.UTC()
+	case "author-local":
+		// today is still the local one
+		authorLocal = true
+	default:
+		loc, err := time.LoadLocation(f.tz)
+		if err != nil {
+			return timeRange{}, fmt.Errorf("invalid -tz %q, expected local, UTC, author-local or a zone name like Europe/Rome", f.tz)
+		}
+		now = now.In(loc)
+	}
+	r, err := f.dates(now)
+	r.authorLocal = authorLocal
+	return r, err
+}
+
+// dates returns the first and the last day selected by the flags,
+// in the timezone of `now`
+func (f *rangeFlags) dates(now time.Time) (timeRange, error) {
+	today := getBeginningOfDay(now)
+
+	if f.year != 0 {
+		if f.since != "" || f.until != "" {
+			return timeRange{}, fmt.Errorf("-year can't be used with -since or -until")
+		}
+		since := time.Date(f.year, time.January, 1, 0, 0, 0, 0, today.Location())
+		return timeRange{since: since, until: since.AddDate(1, 0, -1)}, nil
+	}
+
+	r := timeRange{until: today}
+	if f.until != "" {
+		until, err := parseDate(f.until, today)
+		if err != nil {
+			return timeRange{}, fmt.Errorf("invalid -until: %v", err)
+		}
+		r.until = until
+	}
+
+	r.since = r.until.AddDate(0, 0, -(defaultDays - 1))
+	if f.since != "" {
+		since, err := parseDate(f.since, today)
+		if err != nil {
+			return timeRange{}, fmt.Errorf("invalid -since: %v", err)
+		}
+		if durationRegexp.MatchString(f.since) {
+			// like the default range, 90d are 90 days including today
+			since = since.AddDate(0, 0, 1)
+		}
+		r.since = since
+	}
+
+	if r.since.After(r.until) {
+		return timeRange{}, fmt.Errorf("-since must not be after -until")
+	}
+
+	return r, nil
+}
+
+var durationRegexp = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+// parseDate parses `value`, either a YYYY-MM-DD date or a duration
+// like 90d, 12w, 6m or 1y counted back from `today`
+func parseDate(value string, today time.Time) (time.Time, error) {
+	if m := durationRegexp.FindStringSubmatch(value); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, err
+		}
+		switch m[2] {
+		case "d":
+			return today.AddDate(0, 0, -n), nil
+		case "w":
+			return today.AddDate(0, 0, -7*n), nil
+		case "m":
+			return today.AddDate(0, -n, 0), nil
+		default:
+			return today.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, today.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a YYYY-MM-DD date or a duration like 90d", value)
+	}
+	return date, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// mustLoadLocation returns the `name` timezone, failing the test if missing
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("loading %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestDaysBetweenAcrossDST(t *testing.T) {
+	rome := mustLoadLocation(t, "Europe/Rome")
+	la := mustLoadLocation(t, "America/Los_Angeles")
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want int
+	}{
+		{"Rome spring forward", time.Date(2026, 3, 28, 0, 0, 0, 0, rome), time.Date(2026, 3, 30, 0, 0, 0, 0, rome), 2},
+		{"Rome spring forward day", time.Date(2026, 3, 29, 0, 0, 0, 0, rome), time.Date(2026, 3, 29, 23, 59, 0, 0, rome), 0},
+		{"Rome fall back", time.Date(2026, 10, 24, 0, 0, 0, 0, rome), time.Date(2026, 10, 26, 0, 0, 0, 0, rome), 2},
+		{"Rome fall back day", time.Date(2026, 10, 25, 0, 0, 0, 0, rome), time.Date(2026, 10, 26, 0, 0, 0, 0, rome), 1},
+		{"LA spring forward", time.Date(2026, 3, 7, 0, 0, 0, 0, la), time.Date(2026, 3, 9, 0, 0, 0, 0, la), 2},
+		{"LA fall back", time.Date(2026, 10, 31, 23, 0, 0, 0, la), time.Date(2026, 11, 2, 0, 30, 0, 0, la), 2},
+		{"whole year", time.Date(2026, 1, 1, 0, 0, 0, 0, rome), time.Date(2026, 12, 31, 0, 0, 0, 0, rome), 364},
+		{"backwards", time.Date(2026, 3, 30, 0, 0, 0, 0, rome), time.Date(2026, 3, 28, 0, 0, 0, 0, rome), -2},
+	}
+	for _, tt := range tests {
+		if got := daysBetween(tt.from, tt.to); got != tt.want {
+			t.Errorf("%s: daysBetween(%v, %v) = %d, want %d", tt.name, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestCountDaysSinceDateByZone(t *testing.T) {
+	// 23:30 -0700 on the 28th, 06:30 UTC on the 29th, after Rome moved to CEST
+	lateInLA := time.Date(2026, 3, 28, 23, 30, 0, 0, time.FixedZone("", -7*3600))
+	// 01:30 +0100 on the 29th, 00:30 UTC, just before Rome moved to CEST
+	earlyInRome := time.Date(2026, 3, 29, 1, 30, 0, 0, time.FixedZone("", 3600))
+	springNow := time.Date(2026, 3, 30, 12, 0, 0, 0, time.UTC)
+	// 00:30 +0200 on the 25th, 22:30 UTC on the 24th, the night Rome moved back to CET
+	earlyInRomeFallBack := time.Date(2026, 10, 25, 0, 30, 0, 0, time.FixedZone("", 2*3600))
+	fallNow := time.Date(2026, 10, 27, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		tz     string
+		now    time.Time
+		commit time.Time
+		want   int
+	}{
+		{"UTC", springNow, lateInLA, 1},
+		{"America/Los_Angeles", springNow, lateInLA, 2},
+		{"Europe/Rome", springNow, lateInLA, 1},
+		{"author-local", springNow, lateInLA, 2},
+		{"UTC", springNow, earlyInRome, 1},
+		{"America/Los_Angeles", springNow, earlyInRome, 2},
+		{"Europe/Rome", springNow, earlyInRome, 1},
+		{"author-local", springNow, earlyInRome, 1},
+		{"UTC", fallNow, earlyInRomeFallBack, 3},
+		{"America/Los_Angeles", fallNow, earlyInRomeFallBack, 3},
+		{"Europe/Rome", fallNow, earlyInRomeFallBack, 2},
+		{"author-local", fallNow, earlyInRomeFallBack, 2},
+	}
+	for _, tt := range tests {
+		f := &rangeFlags{since: "7d", tz: tt.tz}
+		r, err := f.timeRange(tt.now)
+		if err != nil {
+			t.Fatalf("-tz %s: %v", tt.tz, err)
+		}
+		if got := countDaysSinceDate(tt.commit, r); got != tt.want {
+			t.Errorf("-tz %s: countDaysSinceDate(%v) = %d, want %d", tt.tz, tt.commit, got, tt.want)
+		}
+	}
+}
+
+func TestTimeRangeZones(t *testing.T) {
+	now := time.Date(2026, 3, 30, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		tz      string
+		wantErr bool
+	}{
+		{"", false},
+		{"local", false},
+		{"UTC", false},
+		{"utc", false},
+		{"author-local", false},
+		{"Europe/Rome", false},
+		{"Mars/Olympus_Mons", true},
+		{"+0200", true},
+	}
+	for _, tt := range tests {
+		f := &rangeFlags{tz: tt.tz}
+		_, err := f.timeRange(now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("-tz %q: got error %v, want error %v", tt.tz, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTimeRangeYearWithZone(t *testing.T) {
+	la := mustLoadLocation(t, "America/Los_Angeles")
+	// already 2027 in UTC, still 2026 in Los Angeles
+	newYearsEve := time.Date(2026, 12, 31, 23, 30, 0, 0, la)
+	now := time.Date(2027, 3, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		tz        string
+		wantSince time.Time
+		wantUntil time.Time
+		// the days ago of newYearsEve, or outOfRange
+		wantDays int
+	}{
+		{"America/Los_Angeles", time.Date(2026, 1, 1, 0, 0, 0, 0, la), time.Date(2026, 12, 31, 0, 0, 0, 0, la), 0},
+		{"UTC", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), outOfRange},
+		{"author-local", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), 0},
+	}
+	for _, tt := range tests {
+		f := &rangeFlags{year: 2026, tz: tt.tz}
+		r, err := f.timeRange(now)
+		if err != nil {
+			t.Fatalf("-year 2026 -tz %s: %v", tt.tz, err)
+		}
+		if !r.since.Equal(tt.wantSince) || !r.until.Equal(tt.wantUntil) {
+			t.Errorf("-year 2026 -tz %s: got %v to %v, want %v to %v", tt.tz, r.since, r.until, tt.wantSince, tt.wantUntil)
+		}
+		if r.days() != 365 {
+			t.Errorf("-year 2026 -tz %s: got %d days, want 365", tt.tz, r.days())
+		}
+		if got := countDaysSinceDate(newYearsEve, r); got != tt.wantDays {
+			t.Errorf("-year 2026 -tz %s: countDaysSinceDate(%v) = %d, want %d", tt.tz, newYearsEve, got, tt.wantDays)
+		}
+	}
+}